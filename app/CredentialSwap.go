@@ -4,6 +4,7 @@
 package app
 
 import (
+	"errors"
 	"math/big"
 	"strings"
 
@@ -17,6 +18,7 @@ import (
 
 // Reference imports to suppress errors if they are not otherwise used.
 var (
+	_ = errors.New
 	_ = big.NewInt
 	_ = strings.NewReader
 	_ = ethereum.NotFound
@@ -59,13 +61,21 @@ type ChannelSubAlloc struct {
 	IndexMap []uint16
 }
 
+// AppMetaData contains all meta data concerning the App contract.
+var AppMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"components\":[{\"internalType\":\"uint256\",\"name\":\"challengeDuration\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"nonce\",\"type\":\"uint256\"},{\"internalType\":\"address[]\",\"name\":\"participants\",\"type\":\"address[]\"},{\"internalType\":\"address\",\"name\":\"app\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"ledgerChannel\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"virtualChannel\",\"type\":\"bool\"}],\"internalType\":\"structChannel.Params\",\"name\":\"params\",\"type\":\"tuple\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"channelID\",\"type\":\"bytes32\"},{\"internalType\":\"uint64\",\"name\":\"version\",\"type\":\"uint64\"},{\"components\":[{\"internalType\":\"address[]\",\"name\":\"assets\",\"type\":\"address[]\"},{\"internalType\":\"uint256[][]\",\"name\":\"balances\",\"type\":\"uint256[][]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"ID\",\"type\":\"bytes32\"},{\"internalType\":\"uint256[]\",\"name\":\"balances\",\"type\":\"uint256[]\"},{\"internalType\":\"uint16[]\",\"name\":\"indexMap\",\"type\":\"uint16[]\"}],\"internalType\":\"structChannel.SubAlloc[]\",\"name\":\"locked\",\"type\":\"tuple[]\"}],\"internalType\":\"structChannel.Allocation\",\"name\":\"outcome\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"appData\",\"type\":\"bytes\"},{\"internalType\":\"bool\",\"name\":\"isFinal\",\"type\":\"bool\"}],\"internalType\":\"structChannel.State\",\"name\":\"from\",\"type\":\"tuple\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"channelID\",\"type\":\"bytes32\"},{\"internalType\":\"uint64\",\"name\":\"version\",\"type\":\"uint64\"},{\"components\":[{\"internalType\":\"address[]\",\"name\":\"assets\",\"type\":\"address[]\"},{\"internalType\":\"uint256[][]\",\"name\":\"balances\",\"type\":\"uint256[][]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"ID\",\"type\":\"bytes32\"},{\"internalType\":\"uint256[]\",\"name\":\"balances\",\"type\":\"uint256[]\"},{\"internalType\":\"uint16[]\",\"name\":\"indexMap\",\"type\":\"uint16[]\"}],\"internalType\":\"structChannel.SubAlloc[]\",\"name\":\"locked\",\"type\":\"tuple[]\"}],\"internalType\":\"structChannel.Allocation\",\"name\":\"outcome\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"appData\",\"type\":\"bytes\"},{\"internalType\":\"bool\",\"name\":\"isFinal\",\"type\":\"bool\"}],\"internalType\":\"structChannel.State\",\"name\":\"to\",\"type\":\"tuple\"},{\"internalType\":\"uint256\",\"name\":\"actorIdx\",\"type\":\"uint256\"}],\"name\":\"validTransition\",\"outputs\":[],\"stateMutability\":\"pure\",\"type\":\"function\"}]",
+	Sigs: map[string]string{
+		"0d1feb4f": "validTransition((uint256,uint256,address[],address,bool,bool),(bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool),(bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool),uint256)",
+	},
+}
+
 // AppABI is the input ABI used to generate the binding from.
-const AppABI = "[{\"inputs\":[{\"components\":[{\"internalType\":\"uint256\",\"name\":\"challengeDuration\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"nonce\",\"type\":\"uint256\"},{\"internalType\":\"address[]\",\"name\":\"participants\",\"type\":\"address[]\"},{\"internalType\":\"address\",\"name\":\"app\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"ledgerChannel\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"virtualChannel\",\"type\":\"bool\"}],\"internalType\":\"structChannel.Params\",\"name\":\"params\",\"type\":\"tuple\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"channelID\",\"type\":\"bytes32\"},{\"internalType\":\"uint64\",\"name\":\"version\",\"type\":\"uint64\"},{\"components\":[{\"internalType\":\"address[]\",\"name\":\"assets\",\"type\":\"address[]\"},{\"internalType\":\"uint256[][]\",\"name\":\"balances\",\"type\":\"uint256[][]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"ID\",\"type\":\"bytes32\"},{\"internalType\":\"uint256[]\",\"name\":\"balances\",\"type\":\"uint256[]\"},{\"internalType\":\"uint16[]\",\"name\":\"indexMap\",\"type\":\"uint16[]\"}],\"internalType\":\"structChannel.SubAlloc[]\",\"name\":\"locked\",\"type\":\"tuple[]\"}],\"internalType\":\"structChannel.Allocation\",\"name\":\"outcome\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"appData\",\"type\":\"bytes\"},{\"internalType\":\"bool\",\"name\":\"isFinal\",\"type\":\"bool\"}],\"internalType\":\"structChannel.State\",\"name\":\"from\",\"type\":\"tuple\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"channelID\",\"type\":\"bytes32\"},{\"internalType\":\"uint64\",\"name\":\"version\",\"type\":\"uint64\"},{\"components\":[{\"internalType\":\"address[]\",\"name\":\"assets\",\"type\":\"address[]\"},{\"internalType\":\"uint256[][]\",\"name\":\"balances\",\"type\":\"uint256[][]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"ID\",\"type\":\"bytes32\"},{\"internalType\":\"uint256[]\",\"name\":\"balances\",\"type\":\"uint256[]\"},{\"internalType\":\"uint16[]\",\"name\":\"indexMap\",\"type\":\"uint16[]\"}],\"internalType\":\"structChannel.SubAlloc[]\",\"name\":\"locked\",\"type\":\"tuple[]\"}],\"internalType\":\"structChannel.Allocation\",\"name\":\"outcome\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"appData\",\"type\":\"bytes\"},{\"internalType\":\"bool\",\"name\":\"isFinal\",\"type\":\"bool\"}],\"internalType\":\"structChannel.State\",\"name\":\"to\",\"type\":\"tuple\"},{\"internalType\":\"uint256\",\"name\":\"actorIdx\",\"type\":\"uint256\"}],\"name\":\"validTransition\",\"outputs\":[],\"stateMutability\":\"pure\",\"type\":\"function\"}]"
+// Deprecated: Use AppMetaData.ABI instead.
+var AppABI = AppMetaData.ABI
 
+// Deprecated: Use AppMetaData.Sigs instead.
 // AppFuncSigs maps the 4-byte function signature to its string representation.
-var AppFuncSigs = map[string]string{
-	"0d1feb4f": "validTransition((uint256,uint256,address[],address,bool,bool),(bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool),(bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool),uint256)",
-}
+var AppFuncSigs = AppMetaData.Sigs
 
 // App is an auto generated Go binding around an Ethereum contract.
 type App struct {
@@ -238,20 +248,31 @@ func (_App *AppCallerSession) ValidTransition(params ChannelParams, from Channel
 	return _App.Contract.ValidTransition(&_App.CallOpts, params, from, to, actorIdx)
 }
 
+// ArrayMetaData contains all meta data concerning the Array contract.
+var ArrayMetaData = &bind.MetaData{
+	ABI: "[]",
+	Bin: "0x60566037600b82828239805160001a607314602a57634e487b7160e01b600052600060045260246000fd5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea2646970667358221220f3ead741e02264d8fdfa25f5662e247bb9c43d7357835f89734a3d45cd3c020664736f6c63430008150033",
+}
+
 // ArrayABI is the input ABI used to generate the binding from.
-const ArrayABI = "[]"
+// Deprecated: Use ArrayMetaData.ABI instead.
+var ArrayABI = ArrayMetaData.ABI
 
 // ArrayBin is the compiled bytecode used for deploying new contracts.
-var ArrayBin = "0x60566023600b82828239805160001a607314601657fe5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea26469706673582212209f4d25c7e3cd971c189df97503706b5ca3a995a0082abe18c64da43c672f747264736f6c63430007060033"
+// Deprecated: Use ArrayMetaData.Bin instead.
+var ArrayBin = ArrayMetaData.Bin
 
 // DeployArray deploys a new Ethereum contract, binding an instance of Array to it.
 func DeployArray(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *Array, error) {
-	parsed, err := abi.JSON(strings.NewReader(ArrayABI))
+	parsed, err := ArrayMetaData.GetAbi()
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
 
-	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(ArrayBin), backend)
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(ArrayBin), backend)
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
@@ -400,20 +421,31 @@ func (_Array *ArrayTransactorRaw) Transact(opts *bind.TransactOpts, method strin
 	return _Array.Contract.contract.Transact(opts, method, params...)
 }
 
+// ChannelMetaData contains all meta data concerning the Channel contract.
+var ChannelMetaData = &bind.MetaData{
+	ABI: "[]",
+	Bin: "0x60566037600b82828239805160001a607314602a57634e487b7160e01b600052600060045260246000fd5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea2646970667358221220e1be6aaa9e2c3b22f4ec4ea425818f02905082b3d4d7dca9aaef90605afed5b564736f6c63430008150033",
+}
+
 // ChannelABI is the input ABI used to generate the binding from.
-const ChannelABI = "[]"
+// Deprecated: Use ChannelMetaData.ABI instead.
+var ChannelABI = ChannelMetaData.ABI
 
 // ChannelBin is the compiled bytecode used for deploying new contracts.
-var ChannelBin = "0x60566023600b82828239805160001a607314601657fe5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea2646970667358221220ebe3379116f0685b95af4da576c211ec5b18abdb3e8fe45d14a084f0dac865ef64736f6c63430007060033"
+// Deprecated: Use ChannelMetaData.Bin instead.
+var ChannelBin = ChannelMetaData.Bin
 
 // DeployChannel deploys a new Ethereum contract, binding an instance of Channel to it.
 func DeployChannel(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *Channel, error) {
-	parsed, err := abi.JSON(strings.NewReader(ChannelABI))
+	parsed, err := ChannelMetaData.GetAbi()
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
 
-	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(ChannelBin), backend)
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(ChannelBin), backend)
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
@@ -562,25 +594,38 @@ func (_Channel *ChannelTransactorRaw) Transact(opts *bind.TransactOpts, method s
 	return _Channel.Contract.contract.Transact(opts, method, params...)
 }
 
+// CredentialSwapMetaData contains all meta data concerning the CredentialSwap contract.
+var CredentialSwapMetaData = &bind.MetaData{
+	ABI: "[{\"inputs\":[{\"components\":[{\"internalType\":\"uint256\",\"name\":\"challengeDuration\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"nonce\",\"type\":\"uint256\"},{\"internalType\":\"address[]\",\"name\":\"participants\",\"type\":\"address[]\"},{\"internalType\":\"address\",\"name\":\"app\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"ledgerChannel\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"virtualChannel\",\"type\":\"bool\"}],\"internalType\":\"structChannel.Params\",\"name\":\"params\",\"type\":\"tuple\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"channelID\",\"type\":\"bytes32\"},{\"internalType\":\"uint64\",\"name\":\"version\",\"type\":\"uint64\"},{\"components\":[{\"internalType\":\"address[]\",\"name\":\"assets\",\"type\":\"address[]\"},{\"internalType\":\"uint256[][]\",\"name\":\"balances\",\"type\":\"uint256[][]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"ID\",\"type\":\"bytes32\"},{\"internalType\":\"uint256[]\",\"name\":\"balances\",\"type\":\"uint256[]\"},{\"internalType\":\"uint16[]\",\"name\":\"indexMap\",\"type\":\"uint16[]\"}],\"internalType\":\"structChannel.SubAlloc[]\",\"name\":\"locked\",\"type\":\"tuple[]\"}],\"internalType\":\"structChannel.Allocation\",\"name\":\"outcome\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"appData\",\"type\":\"bytes\"},{\"internalType\":\"bool\",\"name\":\"isFinal\",\"type\":\"bool\"}],\"internalType\":\"structChannel.State\",\"name\":\"cur\",\"type\":\"tuple\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"channelID\",\"type\":\"bytes32\"},{\"internalType\":\"uint64\",\"name\":\"version\",\"type\":\"uint64\"},{\"components\":[{\"internalType\":\"address[]\",\"name\":\"assets\",\"type\":\"address[]\"},{\"internalType\":\"uint256[][]\",\"name\":\"balances\",\"type\":\"uint256[][]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"ID\",\"type\":\"bytes32\"},{\"internalType\":\"uint256[]\",\"name\":\"balances\",\"type\":\"uint256[]\"},{\"internalType\":\"uint16[]\",\"name\":\"indexMap\",\"type\":\"uint16[]\"}],\"internalType\":\"structChannel.SubAlloc[]\",\"name\":\"locked\",\"type\":\"tuple[]\"}],\"internalType\":\"structChannel.Allocation\",\"name\":\"outcome\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"appData\",\"type\":\"bytes\"},{\"internalType\":\"bool\",\"name\":\"isFinal\",\"type\":\"bool\"}],\"internalType\":\"structChannel.State\",\"name\":\"next\",\"type\":\"tuple\"},{\"internalType\":\"uint256\",\"name\":\"actor\",\"type\":\"uint256\"}],\"name\":\"validTransition\",\"outputs\":[],\"stateMutability\":\"pure\",\"type\":\"function\"}]",
+	Sigs: map[string]string{
+		"0d1feb4f": "validTransition((uint256,uint256,address[],address,bool,bool),(bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool),(bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool),uint256)",
+	},
+	Bin: "0x608060405234801561001057600080fd5b506130e2806100206000396000f3fe608060405234801561001057600080fd5b506004361061002b5760003560e01c80630d1feb4f14610030575b600080fd5b61004361003e366004612478565b610045565b005b61004f8383610205565b600061005a846103ec565b90506000610067846103ec565b9050600061007483610493565b9050600260ff16826000015160ff16036100b05760006100978360200151610545565b90506100a78883838a8a8a6105d7565b505050506101ff565b6100ba8686610b90565b60006100c583610493565b90506100d48883838989610be4565b825160ff16600219016101fa5760006100f08460200151610f8a565b90506000600360ff16866000015160ff16036101555760006101158760200151610f8a565b90506101248360000151610fcd565b815161012f90610fcd565b1480156101515750826020015180519060200120816020015180519060200120145b9150505b806101f7576101698a898460000151610ffd565b6101a66101798360000151610fcd565b83602001518460000151602001516000815181106101995761019961250f565b60200260200101516113f5565b6101f75760405162461bcd60e51b815260206004820152601760248201527f696e76616c69642071756f7465207369676e617475726500000000000000000060448201526064015b60405180910390fd5b50505b505050505b50505050565b6000806102156040850185612525565b61021f9080612545565b61022c6040860186612525565b6102369080612545565b808060200260200160405190810160405280939291908181526020018383602002808284376000920191909152505060408051602080870282810182019093528682529497509594938493508601915084908082843760009201919091525050825192945050506102f35760405162461bcd60e51b815260206004820152602160248201527f696e76616c6964206e756d626572206f66206173736574733a2063757272656e6044820152601d60fa1b60648201526084016101ee565b80518251146103445760405162461bcd60e51b815260206004820152601e60248201527f696e76616c6964206e756d626572206f66206173736574733a206e657874000060448201526064016101ee565b60005b82518110156103e5578181815181106103625761036261250f565b60200260200101516001600160a01b03168382815181106103855761038561250f565b60200260200101516001600160a01b0316146103d35760405162461bcd60e51b815260206004820152600d60248201526c1a5b9d985b1a5908185cdcd95d609a1b60448201526064016101ee565b806103dd816125a4565b915050610347565b5050505050565b604080518082019091526000815260606020820152600260008161041360608601866125bd565b61041e929150612603565b9050600061047161043260608701876125bd565b8080601f016020809104026020016040519081016040528093929190818152602001838380828437600092019190915250505060ff861690508461141b565b90506000818060200190518101906104899190612741565b9695505050505050565b6060600160ff16826000015160ff16036104c55781602001518060200190518101906104bf9190612aa9565b92915050565b815160ff16600119016104e9576104df8260200151610545565b6080015192915050565b815160ff166002190161050d576105038260200151610f8a565b6040015192915050565b604080516000808252602082019092529061053e565b61052b6123e1565b8152602001906001900390816105235790505b5092915050565b6105806040518060a0016040528060006001600160401b03168152602001606081526020016060815260200160608152602001606081525090565b60008060008060008680602001905181019061059c9190612c4f565b6040805160a0810182526001600160401b03909616865260208601949094529284019190915260608301526080820152979650505050505050565b6000806105e8878760000151611521565b91509150806106295760405162461bcd60e51b815260206004820152600d60248201526c3ab735b737bbb71037b33332b960991b60448201526064016101ee565b600087838151811061063d5761063d61250f565b602002602001015190506000849050885188608001515160016106609190612d6f565b146106a65760405162461bcd60e51b8152602060048201526016602482015275696e76616c69642070656e64696e67206f666665727360501b60448201526064016101ee565b60005b8860800151518110156107675760008582106106cf576106ca826001612d6f565b6106d1565b815b90506106f58b82815181106106e8576106e861250f565b6020026020010151610fcd565b61070e8b6080015184815181106106e8576106e861250f565b146107545760405162461bcd60e51b8152602060048201526016602482015275696e76616c69642070656e64696e67206f666665727360501b60448201526064016101ee565b508061075f816125a4565b9150506106a9565b5060e08201516001600160401b03166107866040880160208901612d82565b6001600160401b031611156107cd5760405162461bcd60e51b815260206004820152600d60248201526c1bd999995c88195e1c1a5c9959609a1b60448201526064016101ee565b6107da8a838a8935611593565b60006107e583611a45565b90503660006107f760408b018b612525565b610805906020810190612545565b909250905036600061081a60408c018c612525565b610828906020810190612545565b915091508484848960a0015161ffff168181106108475761084761250f565b90506020028101906108599190612545565b8960c0015161ffff168181106108715761087161250f565b9050602002013510156108bb5760405162461bcd60e51b8152602060048201526012602482015271696e73756666696369656e742066756e647360701b60448201526064016101ee565b8484848960a0015161ffff168181106108d6576108d661250f565b90506020028101906108e89190612545565b8960c0015161ffff168181106109005761090061250f565b905060200201356109119190612603565b82828960a0015161ffff1681811061092b5761092b61250f565b905060200281019061093d9190612545565b8960c0015161ffff168181106109555761095561250f565b90506020020135146109b35760405162461bcd60e51b815260206004820152602160248201527f696e76616c696420616d6f756e74207472616e736665727265643a20627579656044820152603960f91b60648201526084016101ee565b8484848960a0015161ffff168181106109ce576109ce61250f565b90506020028101906109e09190612545565b888181106109f0576109f061250f565b90506020020135610a019190612d6f565b82828960a0015161ffff16818110610a1b57610a1b61250f565b9050602002810190610a2d9190612545565b88818110610a3d57610a3d61250f565b9050602002013514610a9c5760405162461bcd60e51b815260206004820152602260248201527f696e76616c696420616d6f756e74207472616e736665727265643a2073656c6c60448201526132b960f11b60648201526084016101ee565b60005b83811015610b7e578760a0015161ffff168114610b6c57610b6c858583818110610acb57610acb61250f565b9050602002810190610add9190612545565b80806020026020016040519081016040528093929190818152602001838360200280828437600092019190915250879250869150859050818110610b2357610b2361250f565b9050602002810190610b359190612545565b80806020026020016040519081016040528093929190818152602001838360200280828437600092019190915250611a9992505050565b80610b76816125a4565b915050610a9f565b50505050505050505050505050505050565b610be0610ba06040840184612525565b610bae906020810190612545565b610bb791612da6565b610bc46040840184612525565b610bd2906020810190612545565b610bdb91612da6565b611b89565b5050565b6000805b8551811015610d23576000868281518110610c0557610c0561250f565b60200260200101519050600080610c20888460000151611521565b9150915080610cad578260c0015161ffff1686141580610c64575060e08301516001600160401b0316610c596040890160208a01612d82565b6001600160401b0316115b610ca45760405162461bcd60e51b81526020600482015260116024820152701bd999995c881b9bdd08195e1c1a5c9959607a1b60448201526064016101ee565b60019450610d0d565b610cb683610fcd565b610ccb8984815181106106e8576106e861250f565b14610d0d5760405162461bcd60e51b815260206004820152601260248201527134b73b30b634b2103732bc3a1037b33332b960711b60448201526064016101ee565b5050508080610d1b906125a4565b915050610be8565b5060005b8451811015610e27576000858281518110610d4457610d4461250f565b602002602001015190506000610d5e878360000151611521565b509050828114610da25760405162461bcd60e51b815260206004820152600f60248201526e323ab83634b1b0ba329037b33332b960891b60448201526064016101ee565b6000610db2898460000151611521565b91505080610e1157610dc58a8885610ffd565b8260c0015161ffff168614610e0c5760405162461bcd60e51b815260206004820152600d60248201526c34b73b30b634b210313abcb2b960991b60448201526064016101ee565b600194505b5050508080610e1f906125a4565b915050610d27565b5080610ead5760005b8551811015610eab57858181518110610e4b57610e4b61250f565b602002602001015160c0015161ffff168303610e995760405162461bcd60e51b815260206004820152600d60248201526c6f666665722070656e64696e6760981b60448201526064016101ee565b80610ea3816125a4565b915050610e30565b505b60005b8451811015610f7757848181518110610ecb57610ecb61250f565b602002602001015160c0015161ffff1683141580610f295750848181518110610ef657610ef661250f565b602002602001015160e001516001600160401b0316846020016020810190610f1e9190612d82565b6001600160401b0316105b610f655760405162461bcd60e51b815260206004820152600d60248201526c6f666665722070656e64696e6760981b60448201526064016101ee565b80610f6f816125a4565b915050610eb0565b50610f828385611c34565b505050505050565b610f9261243f565b600080600084806020019051810190610fab9190612e6b565b6040805160608101825293845260208401929092529082015295945050505050565b600081604051602001610fe09190612f66565b604051602081830303815290604052805190602001209050919050565b6000816060015151116110525760405162461bcd60e51b815260206004820152601b60248201527f696e76616c6964206f666665723a206e6f20646f63756d656e7473000000000060448201526064016101ee565b806080015151816060015151146110ab5760405162461bcd60e51b815260206004820152601d60248201527f696e76616c6964206f666665723a20756e657175616c206c656e67746800000060448201526064016101ee565b60005b81608001515181101561113a576000826080015182815181106110d3576110d361250f565b6020026020010151116111285760405162461bcd60e51b815260206004820152601960248201527f696e76616c6964206f666665723a207a65726f2070726963650000000000000060448201526064016101ee565b80611132816125a4565b9150506110ae565b506000816040015161ffff161180156111605750806020015151816040015161ffff1611155b6111a05760405162461bcd60e51b81526020600482015260116024820152701a5b9d985b1a59081d1a1c995cda1bdb19607a1b60448201526064016101ee565b60005b81602001515181101561126e5760005b8181101561125b57826020015181815181106111d1576111d161250f565b60200260200101516001600160a01b0316836020015183815181106111f8576111f861250f565b60200260200101516001600160a01b0316036112495760405162461bcd60e51b815260206004820152601060248201526f323ab83634b1b0ba329034b9b9bab2b960811b60448201526064016101ee565b80611253816125a4565b9150506111b3565b5080611266816125a4565b9150506111a3565b5061127c6040830183612525565b6112869080612545565b90508160a0015161ffff16106112ce5760405162461bcd60e51b815260206004820152600d60248201526c1a5b9d985b1a5908185cdcd95d609a1b60448201526064016101ee565b6112db6040840184612545565b90508160c0015161ffff16106113235760405162461bcd60e51b815260206004820152600d60248201526c34b73b30b634b210313abcb2b960991b60448201526064016101ee565b6113336040830160208401612d82565b6001600160401b03168160e001516001600160401b0316116113875760405162461bcd60e51b815260206004820152600d60248201526c1bd999995c88195e1c1a5c9959609a1b60448201526064016101ee565b61012081015160ff1615806113a4575061012081015160ff166001145b6113f05760405162461bcd60e51b815260206004820152601860248201527f696e76616c6964207369676e617475726520736368656d65000000000000000060448201526064016101ee565b505050565b6000806114028585611e1c565b6001600160a01b03908116908416149150509392505050565b60608182601f0110156114615760405162461bcd60e51b815260206004820152600e60248201526d736c6963655f6f766572666c6f7760901b60448201526064016101ee565b61146b8284612d6f565b845110156114af5760405162461bcd60e51b8152602060048201526011602482015270736c6963655f6f75744f66426f756e647360781b60448201526064016101ee565b6060821580156114ce5760405191506000825260208201604052611518565b6040519150601f8416801560200281840101858101878315602002848b0101015b818310156115075780518352602092830192016114ef565b5050858452601f01601f1916604052505b50949350505050565b60008060005b845181101561158357836001600160401b031685828151811061154c5761154c61250f565b6020026020010151600001516001600160401b0316036115715791506001905061158c565b8061157b816125a4565b915050611527565b50600080915091505b9250929050565b602082015151604084015161ffff168110156115e55760405162461bcd60e51b81526020600482015260116024820152701d1a1c995cda1bdb19081b9bdd081b595d607a1b60448201526064016101ee565b60005b818110156116fd578460200151518460200151828151811061160c5761160c61250f565b602002602001015161ffff16106116565760405162461bcd60e51b815260206004820152600e60248201526d34b73b30b634b21039b4b3b732b960911b60448201526064016101ee565b8015806116ae5750602084015161166e600183612603565b8151811061167e5761167e61250f565b602002602001015161ffff16846020015182815181106116a0576116a061250f565b602002602001015161ffff16115b6116eb5760405162461bcd60e51b815260206004820152600e60248201526d34b73b30b634b21039b4b3b732b960911b60448201526064016101ee565b806116f5816125a4565b9150506115e8565b5080836040015151146117525760405162461bcd60e51b815260206004820152601d60248201527f696e76616c6964206e756d626572206f662064656c65676174696f6e7300000060448201526064016101ee565b808460600151516117639190613078565b836060015151146117b65760405162461bcd60e51b815260206004820152601c60248201527f696e76616c6964206e756d626572206f66207369676e6174757265730000000060448201526064016101ee565b6000816001600160401b038111156117d0576117d0612616565b6040519080825280602002602001820160405280156117f9578160200160208202803683370190505b50905060005b828110156118bc57611880868760200151876020015184815181106118265761182661250f565b602002602001015161ffff16815181106118425761184261250f565b6020026020010151876040015184815181106118605761186061250f565b60200260200101518a606001602081019061187b919061308f565b611e8d565b8282815181106118925761189261250f565b6001600160a01b0390921660209283029190910190910152806118b4816125a4565b9150506117ff565b5060006118cc6040880188612545565b8760c0015161ffff168181106118e4576118e461250f565b90506020020160208101906118f9919061308f565b905060005b8660600151518110156101fa5760005b84811015611a32576000611993898a6060015185815181106119325761193261250f565b60200260200101518b602001518b6020015186815181106119555761195561250f565b602002602001015161ffff16815181106119715761197161250f565b60200260200101518a888f606001602081019061198e919061308f565b612058565b90506119df8189606001518489876119ab9190613078565b6119b59190612d6f565b815181106119c5576119c561250f565b60200260200101518785815181106101995761019961250f565b611a1f5760405162461bcd60e51b8152602060048201526011602482015270696e76616c6964207369676e617475726560781b60448201526064016101ee565b5080611a2a816125a4565b91505061190e565b5080611a3d816125a4565b9150506118fe565b6000805b826080015151811015611a935782608001518181518110611a6c57611a6c61250f565b602002602001015182611a7f9190612d6f565b915080611a8b816125a4565b915050611a49565b50919050565b8051825114611aea5760405162461bcd60e51b815260206004820152601960248201527f75696e743235365b5d3a20756e657175616c206c656e6774680000000000000060448201526064016101ee565b60005b82518110156113f057818181518110611b0857611b0861250f565b6020026020010151838281518110611b2257611b2261250f565b602002602001015114611b775760405162461bcd60e51b815260206004820152601760248201527f75696e743235365b5d3a20756e657175616c206974656d00000000000000000060448201526064016101ee565b80611b81816125a4565b915050611aed565b8051825114611bda5760405162461bcd60e51b815260206004820152601b60248201527f75696e743235365b5d5b5d3a20756e657175616c206c656e677468000000000060448201526064016101ee565b60005b82518110156113f057611c22838281518110611bfb57611bfb61250f565b6020026020010151838381518110611c1557611c1561250f565b6020026020010151611a99565b80611c2c816125a4565b915050611bdd565b366000611c446040850185612525565b611c52906020810190612545565b9150915060005b83518110156103e5576000805b8551811015611d4757858381518110611c8157611c8161250f565b602002602001015160a0015161ffff16868281518110611ca357611ca361250f565b602002602001015160a0015161ffff16148015611d015750858381518110611ccd57611ccd61250f565b602002602001015160c0015161ffff16868281518110611cef57611cef61250f565b602002602001015160c0015161ffff16145b15611d3557611d28868281518110611d1b57611d1b61250f565b6020026020010151611a45565b611d329083612d6f565b91505b80611d3f816125a4565b915050611c66565b50808484878581518110611d5d57611d5d61250f565b602002602001015160a0015161ffff16818110611d7c57611d7c61250f565b9050602002810190611d8e9190612545565b878581518110611da057611da061250f565b602002602001015160c0015161ffff16818110611dbf57611dbf61250f565b905060200201351015611e095760405162461bcd60e51b8152602060048201526012602482015271696e73756666696369656e742066756e647360701b60448201526064016101ee565b5080611e14816125a4565b915050611c59565b60008151604114611e6f5760405162461bcd60e51b815260206004820152601f60248201527f45434453413a20696e76616c6964207369676e6174757265206c656e6774680060448201526064016101ee565b60208201516040830151606084015160001a61048986828585612186565b81516000906001600160a01b0316611ea6575082612050565b825160208085015160408087015181517ff9e22fda0252a4c098c21ead0295a7dc4816ef1f932a4e038e8aad326a5a38d9818601526001600160a01b038a81168285015290951660608601526001600160401b0392831660808601529190911660a0808501919091528151808503909101815260c090930190528151910120610140860151600090611f38908561232f565b60405161190160f01b6020820152602281019190915260428101839052606201604051602081830303815290604052805190602001209050611f7f818660600151886113f5565b611fc05760405162461bcd60e51b815260206004820152601260248201527134b73b30b634b2103232b632b3b0ba34b7b760711b60448201526064016101ee565b8661010001516001600160401b031685602001516001600160401b031611158015612006575084604001516001600160401b03168761010001516001600160401b031611155b6120495760405162461bcd60e51b815260206004820152601460248201527319195b1959d85d1a5bdb881b9bdd081d985b1a5960621b60448201526064016101ee565b5050825190505b949350505050565b61012086015160009060ff166120b05760408051602081018890529081018590526001600160a01b0380851660608301528316608082015260a001604051602081830303815290604052805190602001209050610489565b610140870151604080517fcda0c54816bee8c2c3b764be45fdfaad045c887c40ea159d73da47cfbb26e0096020820152908101889052606081018690526001600160a01b03808616608083015280881660a083015260c082019290925290831660e0820152600090610100016040516020818303038152906040528051906020012090506121438861014001518461232f565b60405161190160f01b6020820152602281019190915260428101829052606201604051602081830303815290604052805190602001209150509695505050505050565b60007f7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a08211156122035760405162461bcd60e51b815260206004820152602260248201527f45434453413a20696e76616c6964207369676e6174757265202773272076616c604482015261756560f01b60648201526084016101ee565b8360ff16601b148061221857508360ff16601c145b61226f5760405162461bcd60e51b815260206004820152602260248201527f45434453413a20696e76616c6964207369676e6174757265202776272076616c604482015261756560f01b60648201526084016101ee565b6040805160008082526020820180845288905260ff871692820192909252606081018590526080810184905260019060a0016020604051602081039080840390855afa1580156122c3573d6000803e3d6000fd5b5050604051601f1901519150506001600160a01b0381166123265760405162461bcd60e51b815260206004820152601860248201527f45434453413a20696e76616c6964207369676e6174757265000000000000000060448201526064016101ee565b95945050505050565b604080517f8b73c3c69bb8fe3d512ecc4cf759cc79239f7b179b0ffacaa9a75d522b39400f60208201527fca0e2da54007a5c0b6a08e187a29ef0d958460a01441e99f0d5ab523cff0e255918101919091527fc89efdaa54c0f20c7adf612882df0950f5a951637e0307cdcb4c672f298b8bc66060820152608081018390526001600160a01b03821660a082015260009060c00160405160208183030381529060405280519060200120905092915050565b60408051610160810182526000808252606060208301819052928201819052828201839052608082019290925260a0810182905260c0810182905260e081018290526101008101829052610120810182905261014081019190915290565b60405180606001604052806124526123e1565b815260200160608152602001606081525090565b600060a08284031215611a9357600080fd5b6000806000806080858703121561248e57600080fd5b84356001600160401b03808211156124a557600080fd5b9086019060c082890312156124b957600080fd5b909450602086013590808211156124cf57600080fd5b6124db88838901612466565b945060408701359150808211156124f157600080fd5b506124fe87828801612466565b949793965093946060013593505050565b634e487b7160e01b600052603260045260246000fd5b60008235605e1983360301811261253b57600080fd5b9190910192915050565b6000808335601e1984360301811261255c57600080fd5b8301803591506001600160401b0382111561257657600080fd5b6020019150600581901b360382131561158c57600080fd5b634e487b7160e01b600052601160045260246000fd5b6000600182016125b6576125b661258e565b5060010190565b6000808335601e198436030181126125d457600080fd5b8301803591506001600160401b038211156125ee57600080fd5b60200191503681900382131561158c57600080fd5b818103818111156104bf576104bf61258e565b634e487b7160e01b600052604160045260246000fd5b60405161016081016001600160401b038111828210171561264f5761264f612616565b60405290565b604051608081016001600160401b038111828210171561264f5761264f612616565b604051601f8201601f191681016001600160401b038111828210171561269f5761269f612616565b604052919050565b805160ff811681146126b857600080fd5b919050565b600082601f8301126126ce57600080fd5b81516001600160401b038111156126e7576126e7612616565b60206126fb601f8301601f19168201612677565b828152858284870101111561270f57600080fd5b60005b8381101561272d578581018301518282018401528201612712565b506000928101909101919091529392505050565b60006020828403121561275357600080fd5b81516001600160401b038082111561276a57600080fd5b908301906040828603121561277e57600080fd5b60405160408101818110838211171561279957612799612616565b6040526127a5836126a7565b81526020830151828111156127b957600080fd5b6127c5878286016126bd565b60208301525095945050505050565b60006001600160401b038211156127ed576127ed612616565b5060051b60200190565b6001600160401b038116811461280c57600080fd5b50565b80516126b8816127f7565b6001600160a01b038116811461280c57600080fd5b600082601f83011261284057600080fd5b81516020612855612850836127d4565b612677565b82815260059290921b8401810191818101908684111561287457600080fd5b8286015b8481101561289857805161288b8161281a565b8352918301918301612878565b509695505050505050565b805161ffff811681146126b857600080fd5b600082601f8301126128c657600080fd5b815160206128d6612850836127d4565b82815260059290921b840181019181810190868411156128f557600080fd5b8286015b8481101561289857805183529183019183016128f9565b6000610160828403121561292357600080fd5b61292b61262c565b90506129368261280f565b815260208201516001600160401b038082111561295257600080fd5b61295e8583860161282f565b602084015261296f604085016128a3565b6040840152606084015191508082111561298857600080fd5b612994858386016128b5565b606084015260808401519150808211156129ad57600080fd5b506129ba848285016128b5565b6080830152506129cc60a083016128a3565b60a08201526129dd60c083016128a3565b60c08201526129ee60e0830161280f565b60e0820152610100612a0181840161280f565b90820152610120612a138382016126a7565b818301525061014080830151818301525092915050565b600082601f830112612a3b57600080fd5b81516020612a4b612850836127d4565b82815260059290921b84018101918181019086841115612a6a57600080fd5b8286015b848110156128985780516001600160401b03811115612a8d5760008081fd5b612a9b8986838b0101612910565b845250918301918301612a6e565b600060208284031215612abb57600080fd5b81516001600160401b03811115612ad157600080fd5b61205084828501612a2a565b600082601f830112612aee57600080fd5b81516020612afe612850836127d4565b82815260059290921b84018101918181019086841115612b1d57600080fd5b8286015b848110156128985780516001600160401b0380821115612b415760008081fd5b908801906080828b03601f1901811315612b5b5760008081fd5b612b63612655565b87840151612b708161281a565b8152604084810151612b81816127f7565b828a0152606085810151612b94816127f7565b83830152928501519284841115612bad57600091508182fd5b612bbb8e8b868901016126bd565b90830152508652505050918301918301612b21565b600082601f830112612be157600080fd5b81516020612bf1612850836127d4565b82815260059290921b84018101918181019086841115612c1057600080fd5b8286015b848110156128985780516001600160401b03811115612c335760008081fd5b612c418986838b01016126bd565b845250918301918301612c14565b600080600080600060a08688031215612c6757600080fd5b8551612c72816127f7565b809550506020808701516001600160401b0380821115612c9157600080fd5b818901915089601f830112612ca557600080fd5b8151612cb3612850826127d4565b81815260059190911b8301840190848101908c831115612cd257600080fd5b938501935b82851015612cf757612ce8856128a3565b82529385019390850190612cd7565b60408c01519099509450505080831115612d1057600080fd5b612d1c8a848b01612add565b95506060890151925080831115612d3257600080fd5b612d3e8a848b01612bd0565b94506080890151925080831115612d5457600080fd5b5050612d6288828901612a2a565b9150509295509295909350565b808201808211156104bf576104bf61258e565b600060208284031215612d9457600080fd5b8135612d9f816127f7565b9392505050565b6000612db4612850846127d4565b83815260208082019190600586811b860136811115612dd257600080fd5b865b81811015612e5e5780356001600160401b03811115612df35760008081fd5b880136601f820112612e055760008081fd5b8035612e13612850826127d4565b81815290851b82018601908681019036831115612e305760008081fd5b928701925b82841015612e4e57833582529287019290870190612e35565b8952505050948301948301612dd4565b5092979650505050505050565b600080600060608486031215612e8057600080fd5b83516001600160401b0380821115612e9757600080fd5b612ea387838801612910565b94506020860151915080821115612eb957600080fd5b612ec5878388016126bd565b93506040860151915080821115612edb57600080fd5b50612ee886828701612a2a565b9150509250925092565b600081518084526020808501945080840160005b83811015612f2b5781516001600160a01b031687529582019590820190600101612f06565b509495945050505050565b600081518084526020808501945080840160005b83811015612f2b57815187529582019590820190600101612f4a565b60208152612f806020820183516001600160401b03169052565b60006020830151610160806040850152612f9e610180850183612ef2565b91506040850151612fb5606086018261ffff169052565b506060850151601f1980868503016080870152612fd28483612f36565b935060808701519150808685030160a087015250612ff08382612f36565b92505060a085015161300860c086018261ffff169052565b5060c085015161ffff811660e08601525060e0850151610100613035818701836001600160401b03169052565b8601519050610120613051868201836001600160401b03169052565b86015190506101406130678682018360ff169052565b959095015193019290925250919050565b80820281158282048414176104bf576104bf61258e565b6000602082840312156130a157600080fd5b8135612d9f8161281a56fea26469706673582212207227c924d7653719fe6a8b9d56591a033b46263dd947e90cdab99ea3d17da47664736f6c63430008150033",
+}
+
 // CredentialSwapABI is the input ABI used to generate the binding from.
-const CredentialSwapABI = "[{\"inputs\":[{\"components\":[{\"internalType\":\"uint256\",\"name\":\"challengeDuration\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"nonce\",\"type\":\"uint256\"},{\"internalType\":\"address[]\",\"name\":\"participants\",\"type\":\"address[]\"},{\"internalType\":\"address\",\"name\":\"app\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"ledgerChannel\",\"type\":\"bool\"},{\"internalType\":\"bool\",\"name\":\"virtualChannel\",\"type\":\"bool\"}],\"internalType\":\"structChannel.Params\",\"name\":\"\",\"type\":\"tuple\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"channelID\",\"type\":\"bytes32\"},{\"internalType\":\"uint64\",\"name\":\"version\",\"type\":\"uint64\"},{\"components\":[{\"internalType\":\"address[]\",\"name\":\"assets\",\"type\":\"address[]\"},{\"internalType\":\"uint256[][]\",\"name\":\"balances\",\"type\":\"uint256[][]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"ID\",\"type\":\"bytes32\"},{\"internalType\":\"uint256[]\",\"name\":\"balances\",\"type\":\"uint256[]\"},{\"internalType\":\"uint16[]\",\"name\":\"indexMap\",\"type\":\"uint16[]\"}],\"internalType\":\"structChannel.SubAlloc[]\",\"name\":\"locked\",\"type\":\"tuple[]\"}],\"internalType\":\"structChannel.Allocation\",\"name\":\"outcome\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"appData\",\"type\":\"bytes\"},{\"internalType\":\"bool\",\"name\":\"isFinal\",\"type\":\"bool\"}],\"internalType\":\"structChannel.State\",\"name\":\"cur\",\"type\":\"tuple\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"channelID\",\"type\":\"bytes32\"},{\"internalType\":\"uint64\",\"name\":\"version\",\"type\":\"uint64\"},{\"components\":[{\"internalType\":\"address[]\",\"name\":\"assets\",\"type\":\"address[]\"},{\"internalType\":\"uint256[][]\",\"name\":\"balances\",\"type\":\"uint256[][]\"},{\"components\":[{\"internalType\":\"bytes32\",\"name\":\"ID\",\"type\":\"bytes32\"},{\"internalType\":\"uint256[]\",\"name\":\"balances\",\"type\":\"uint256[]\"},{\"internalType\":\"uint16[]\",\"name\":\"indexMap\",\"type\":\"uint16[]\"}],\"internalType\":\"structChannel.SubAlloc[]\",\"name\":\"locked\",\"type\":\"tuple[]\"}],\"internalType\":\"structChannel.Allocation\",\"name\":\"outcome\",\"type\":\"tuple\"},{\"internalType\":\"bytes\",\"name\":\"appData\",\"type\":\"bytes\"},{\"internalType\":\"bool\",\"name\":\"isFinal\",\"type\":\"bool\"}],\"internalType\":\"structChannel.State\",\"name\":\"next\",\"type\":\"tuple\"},{\"internalType\":\"uint256\",\"name\":\"actor\",\"type\":\"uint256\"}],\"name\":\"validTransition\",\"outputs\":[],\"stateMutability\":\"pure\",\"type\":\"function\"}]"
+// Deprecated: Use CredentialSwapMetaData.ABI instead.
+var CredentialSwapABI = CredentialSwapMetaData.ABI
 
+// Deprecated: Use CredentialSwapMetaData.Sigs instead.
 // CredentialSwapFuncSigs maps the 4-byte function signature to its string representation.
-var CredentialSwapFuncSigs = map[string]string{
-	"0d1feb4f": "validTransition((uint256,uint256,address[],address,bool,bool),(bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool),(bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool),uint256)",
-}
+var CredentialSwapFuncSigs = CredentialSwapMetaData.Sigs
 
 // CredentialSwapBin is the compiled bytecode used for deploying new contracts.
-var CredentialSwapBin = "0x608060405234801561001057600080fd5b5061108b806100206000396000f3fe608060405234801561001057600080fd5b506004361061002b5760003560e01c80630d1feb4f14610030575b600080fd5b61004361003e366004610c07565b610045565b005b61004f8383610155565b600061005a84610292565b805190915060ff166001141561008d5760006100798260200151610327565b90506100878186868661034c565b5061014e565b6100978484610516565b60006100a284610292565b805190915060ff166001141561014c5760006100c18260200151610327565b90503660006100d36040880188610f08565b6100e1906020810190610e75565b6040850151919350915082826000816100f657fe5b90506020028101906101089190610e75565b856060015161ffff1681811061011a57fe5b9050602002013510156101485760405162461bcd60e51b815260040161013f90610e49565b60405180910390fd5b5050505b505b5050505050565b6000806101656040850185610f08565b61016f9080610e75565b61017c6040860186610f08565b6101869080610e75565b80806020026020016040519081016040528093929190818152602001838360200280828437600092019190915250506040805160208087028281018201909352868252949750959493849350860191508490808284376000920191909152505082519294506001928314915061021090505760405162461bcd60e51b815260040161013f90610d90565b8060ff168251146102335760405162461bcd60e51b815260040161013f90610d59565b81600060ff168151811061024357fe5b60200260200101516001600160a01b031683600060ff168151811061026457fe5b60200260200101516001600160a01b03161461014e5760405162461bcd60e51b815260040161013f90610cc5565b61029a610a27565b60026000816102ac6060860186610ec3565b905003905060006103058580606001906102c69190610ec3565b8080601f016020809104026020016040519081016040528093929190818152602001838380828437600092019190915250505060ff861690508461056a565b905060008180602001905181019061031d9190610a90565b9695505050505050565b61032f610a3f565b6000828060200190518101906103459190610b88565b9392505050565b60008061035884610673565b91509150806103795760405162461bcd60e51b815260040161013f90610d2e565b602086015182518751859261038f9290916106d2565b6103ab5760405162461bcd60e51b815260040161013f90610c9a565b3660006103bb6040890189610f08565b6103c9906020810190610e75565b90925090503660006103de60408a018a610f08565b6103ec906020810190610e75565b60408d01519193509150848460008161040157fe5b90506020028101906104139190610e75565b8d6060015161ffff1681811061042557fe5b90506020020135038282600060ff1681811061043d57fe5b905060200281019061044f9190610e75565b8d6060015161ffff1681811061046157fe5b90506020020135146104855760405162461bcd60e51b815260040161013f90610dd1565b60408b0151848460008161049557fe5b90506020028101906104a79190610e75565b878181106104b157fe5b90506020020135018282600060ff168181106104c957fe5b90506020028101906104db9190610e75565b878181106104e557fe5b90506020020135146105095760405162461bcd60e51b815260040161013f90610cec565b5050505050505050505050565b6105666105266040840184610f08565b610534906020810190610e75565b61053d91610f69565b61054a6040840184610f08565b610558906020810190610e75565b61056191610f69565b6106f8565b5050565b60608182601f0110156105b5576040805162461bcd60e51b815260206004820152600e60248201526d736c6963655f6f766572666c6f7760901b604482015290519081900360640190fd5b81830184511015610601576040805162461bcd60e51b8152602060048201526011602482015270736c6963655f6f75744f66426f756e647360781b604482015290519081900360640190fd5b606082158015610620576040519150600082526020820160405261066a565b6040519150601f8416801560200281840101858101878315602002848b0101015b81831015610659578051835260209283019201610641565b5050858452601f01601f1916604052505b50949350505050565b61067b610a66565b60008061068784610292565b805190915060ff166002146106b55750506040805180820190915260006020820181815282529091506106cd565b60408051602080820190925291015181529150600190505b915091565b6000806106df8585610762565b6001600160a01b03908116908416149150509392505050565b80518251146107195760405162461bcd60e51b815260040161013f90610e12565b60005b825181101561075d5761075583828151811061073457fe5b602002602001015183838151811061074857fe5b6020026020010151610943565b60010161071c565b505050565b600081516041146107ba576040805162461bcd60e51b815260206004820152601f60248201527f45434453413a20696e76616c6964207369676e6174757265206c656e67746800604482015290519081900360640190fd5b60208201516040830151606084015160001a7f7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a082111561082b5760405162461bcd60e51b81526004018080602001828103825260228152602001806110126022913960400191505060405180910390fd5b8060ff16601b1415801561084357508060ff16601c14155b1561087f5760405162461bcd60e51b81526004018080602001828103825260228152602001806110346022913960400191505060405180910390fd5b600060018783868660405160008152602001604052604051808581526020018460ff1681526020018381526020018281526020019450505050506020604051602081039080840390855afa1580156108db573d6000803e3d6000fd5b5050604051601f1901519150506001600160a01b03811661031d576040805162461bcd60e51b815260206004820152601860248201527f45434453413a20696e76616c6964207369676e61747572650000000000000000604482015290519081900360640190fd5b8051825114610999576040805162461bcd60e51b815260206004820152601960248201527f75696e743235365b5d3a20756e657175616c206c656e67746800000000000000604482015290519081900360640190fd5b60005b825181101561075d578181815181106109b157fe5b60200260200101518382815181106109c557fe5b602002602001015114610a1f576040805162461bcd60e51b815260206004820152601760248201527f75696e743235365b5d3a20756e657175616c206974656d000000000000000000604482015290519081900360640190fd5b60010161099c565b60408051808201909152600081526060602082015290565b60408051608081018252600080825260208201819052918101829052606081019190915290565b6040518060200160405280606081525090565b600060a08284031215610a8a578081fd5b50919050565b60006020808385031215610aa2578182fd5b825167ffffffffffffffff80821115610ab9578384fd5b9084019060408287031215610acc578384fd5b604051604081018181108382111715610ae157fe5b604052825160ff81168114610af4578586fd5b81528284015182811115610b06578586fd5b80840193505086601f840112610b1a578485fd5b825182811115610b2657fe5b610b38601f8201601f19168601610f27565b92508083528785828601011115610b4d578586fd5b855b81811015610b6a578481018601518482018701528501610b4f565b81811115610b7a57868683860101525b505092830152509392505050565b600060808284031215610b99578081fd5b6040516080810181811067ffffffffffffffff82111715610bb657fe5b60405282516001600160a01b0381168114610bcf578283fd5b808252506020830151602082015260408301516040820152606083015161ffff81168114610bfb578283fd5b60608201529392505050565b60008060008060808587031215610c1c578283fd5b843567ffffffffffffffff80821115610c33578485fd5b9086019060c08289031215610c46578485fd5b90945060208601359080821115610c5b578485fd5b610c6788838901610a79565b94506040870135915080821115610c7c578384fd5b50610c8987828801610a79565b949793965093946060013593505050565b602080825260119082015270696e76616c6964207369676e617475726560781b604082015260600190565b6020808252600d908201526c1a5b9d985b1a5908185cdcd95d609a1b604082015260600190565b60208082526022908201527f696e76616c696420616d6f756e74207472616e736665727265643a2073656c6c60408201526132b960f11b606082015260800190565b602080825260119082015270696e76616c6964206e657874206d6f646560781b604082015260600190565b6020808252601e908201527f696e76616c6964206e756d626572206f66206173736574733a206e6578740000604082015260600190565b60208082526021908201527f696e76616c6964206e756d626572206f66206173736574733a2063757272656e6040820152601d60fa1b606082015260800190565b60208082526021908201527f696e76616c696420616d6f756e74207472616e736665727265643a20627579656040820152603960f91b606082015260800190565b6020808252601b908201527f75696e743235365b5d5b5d3a20756e657175616c206c656e6774680000000000604082015260600190565b602080825260129082015271696e73756666696369656e742066756e647360701b604082015260600190565b6000808335601e19843603018112610e8b578283fd5b83018035915067ffffffffffffffff821115610ea5578283fd5b6020908101925081023603821315610ebc57600080fd5b9250929050565b6000808335601e19843603018112610ed9578283fd5b83018035915067ffffffffffffffff821115610ef3578283fd5b602001915036819003821315610ebc57600080fd5b60008235605e19833603018112610f1d578182fd5b9190910192915050565b60405181810167ffffffffffffffff81118282101715610f4357fe5b604052919050565b600067ffffffffffffffff821115610f5f57fe5b5060209081020190565b6000610f7c610f7784610f4b565b610f27565b8381526020808201919084845b87811015611005578135870136601f820112610fa3578687fd5b8035610fb1610f7782610f4b565b8181528581019083870136888502860189011115610fcd578a8bfd5b8a94505b83851015610fef578035835260019490940193918701918701610fd1565b5088525050509382019390820190600101610f89565b5091969550505050505056fe45434453413a20696e76616c6964207369676e6174757265202773272076616c756545434453413a20696e76616c6964207369676e6174757265202776272076616c7565a2646970667358221220cfcc15bea829ce7b872ad4fbfbdce0336d3e14594ec9223d90e2040d3fcfa9e064736f6c63430007060033"
+// Deprecated: Use CredentialSwapMetaData.Bin instead.
+var CredentialSwapBin = CredentialSwapMetaData.Bin
 
 // DeployCredentialSwap deploys a new Ethereum contract, binding an instance of CredentialSwap to it.
 func DeployCredentialSwap(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *CredentialSwap, error) {
-	parsed, err := abi.JSON(strings.NewReader(CredentialSwapABI))
+	parsed, err := CredentialSwapMetaData.GetAbi()
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
 
-	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(CredentialSwapBin), backend)
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(CredentialSwapBin), backend)
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
@@ -731,10 +776,10 @@ func (_CredentialSwap *CredentialSwapTransactorRaw) Transact(opts *bind.Transact
 
 // ValidTransition is a free data retrieval call binding the contract method 0x0d1feb4f.
 //
-// Solidity: function validTransition((uint256,uint256,address[],address,bool,bool) , (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) cur, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) next, uint256 actor) pure returns()
-func (_CredentialSwap *CredentialSwapCaller) ValidTransition(opts *bind.CallOpts, arg0 ChannelParams, cur ChannelState, next ChannelState, actor *big.Int) error {
+// Solidity: function validTransition((uint256,uint256,address[],address,bool,bool) params, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) cur, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) next, uint256 actor) pure returns()
+func (_CredentialSwap *CredentialSwapCaller) ValidTransition(opts *bind.CallOpts, params ChannelParams, cur ChannelState, next ChannelState, actor *big.Int) error {
 	var out []interface{}
-	err := _CredentialSwap.contract.Call(opts, &out, "validTransition", arg0, cur, next, actor)
+	err := _CredentialSwap.contract.Call(opts, &out, "validTransition", params, cur, next, actor)
 
 	if err != nil {
 		return err
@@ -746,32 +791,43 @@ func (_CredentialSwap *CredentialSwapCaller) ValidTransition(opts *bind.CallOpts
 
 // ValidTransition is a free data retrieval call binding the contract method 0x0d1feb4f.
 //
-// Solidity: function validTransition((uint256,uint256,address[],address,bool,bool) , (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) cur, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) next, uint256 actor) pure returns()
-func (_CredentialSwap *CredentialSwapSession) ValidTransition(arg0 ChannelParams, cur ChannelState, next ChannelState, actor *big.Int) error {
-	return _CredentialSwap.Contract.ValidTransition(&_CredentialSwap.CallOpts, arg0, cur, next, actor)
+// Solidity: function validTransition((uint256,uint256,address[],address,bool,bool) params, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) cur, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) next, uint256 actor) pure returns()
+func (_CredentialSwap *CredentialSwapSession) ValidTransition(params ChannelParams, cur ChannelState, next ChannelState, actor *big.Int) error {
+	return _CredentialSwap.Contract.ValidTransition(&_CredentialSwap.CallOpts, params, cur, next, actor)
 }
 
 // ValidTransition is a free data retrieval call binding the contract method 0x0d1feb4f.
 //
-// Solidity: function validTransition((uint256,uint256,address[],address,bool,bool) , (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) cur, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) next, uint256 actor) pure returns()
-func (_CredentialSwap *CredentialSwapCallerSession) ValidTransition(arg0 ChannelParams, cur ChannelState, next ChannelState, actor *big.Int) error {
-	return _CredentialSwap.Contract.ValidTransition(&_CredentialSwap.CallOpts, arg0, cur, next, actor)
+// Solidity: function validTransition((uint256,uint256,address[],address,bool,bool) params, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) cur, (bytes32,uint64,(address[],uint256[][],(bytes32,uint256[],uint16[])[]),bytes,bool) next, uint256 actor) pure returns()
+func (_CredentialSwap *CredentialSwapCallerSession) ValidTransition(params ChannelParams, cur ChannelState, next ChannelState, actor *big.Int) error {
+	return _CredentialSwap.Contract.ValidTransition(&_CredentialSwap.CallOpts, params, cur, next, actor)
+}
+
+// DecodeMetaData contains all meta data concerning the Decode contract.
+var DecodeMetaData = &bind.MetaData{
+	ABI: "[]",
+	Bin: "0x60566037600b82828239805160001a607314602a57634e487b7160e01b600052600060045260246000fd5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea26469706673582212202cd464d22e02af3c5b3e8371eec5cd633e9ce34400237258e131c81f99c3541164736f6c63430008150033",
 }
 
 // DecodeABI is the input ABI used to generate the binding from.
-const DecodeABI = "[]"
+// Deprecated: Use DecodeMetaData.ABI instead.
+var DecodeABI = DecodeMetaData.ABI
 
 // DecodeBin is the compiled bytecode used for deploying new contracts.
-var DecodeBin = "0x60566023600b82828239805160001a607314601657fe5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea264697066735822122084ac21a72f6174ad3b6bf4941f013451d27571b7d7fe44d8534ec9bb7ea7e38264736f6c63430007060033"
+// Deprecated: Use DecodeMetaData.Bin instead.
+var DecodeBin = DecodeMetaData.Bin
 
 // DeployDecode deploys a new Ethereum contract, binding an instance of Decode to it.
 func DeployDecode(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *Decode, error) {
-	parsed, err := abi.JSON(strings.NewReader(DecodeABI))
+	parsed, err := DecodeMetaData.GetAbi()
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
 
-	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(DecodeBin), backend)
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(DecodeBin), backend)
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
@@ -920,20 +976,31 @@ func (_Decode *DecodeTransactorRaw) Transact(opts *bind.TransactOpts, method str
 	return _Decode.Contract.contract.Transact(opts, method, params...)
 }
 
+// ECDSAMetaData contains all meta data concerning the ECDSA contract.
+var ECDSAMetaData = &bind.MetaData{
+	ABI: "[]",
+	Bin: "0x60566037600b82828239805160001a607314602a57634e487b7160e01b600052600060045260246000fd5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea26469706673582212205d2c08074a2f9f7a39db19d9b88e421b43d2125d2e68f3d173b905a104302ec464736f6c63430008150033",
+}
+
 // ECDSAABI is the input ABI used to generate the binding from.
-const ECDSAABI = "[]"
+// Deprecated: Use ECDSAMetaData.ABI instead.
+var ECDSAABI = ECDSAMetaData.ABI
 
 // ECDSABin is the compiled bytecode used for deploying new contracts.
-var ECDSABin = "0x60566023600b82828239805160001a607314601657fe5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea26469706673582212206864e8222b45afce4d7d7178eae6e7e5fc2ecf2fb7014b473611c107655370b364736f6c63430007060033"
+// Deprecated: Use ECDSAMetaData.Bin instead.
+var ECDSABin = ECDSAMetaData.Bin
 
 // DeployECDSA deploys a new Ethereum contract, binding an instance of ECDSA to it.
 func DeployECDSA(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *ECDSA, error) {
-	parsed, err := abi.JSON(strings.NewReader(ECDSAABI))
+	parsed, err := ECDSAMetaData.GetAbi()
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
 
-	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(ECDSABin), backend)
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(ECDSABin), backend)
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
@@ -1082,20 +1149,31 @@ func (_ECDSA *ECDSATransactorRaw) Transact(opts *bind.TransactOpts, method strin
 	return _ECDSA.Contract.contract.Transact(opts, method, params...)
 }
 
+// SafeMathMetaData contains all meta data concerning the SafeMath contract.
+var SafeMathMetaData = &bind.MetaData{
+	ABI: "[]",
+	Bin: "0x60566037600b82828239805160001a607314602a57634e487b7160e01b600052600060045260246000fd5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea2646970667358221220dcbd8cd416cad2efd57677ee7c1e7dea4d736588ae1f690921e9ce219a6cc89864736f6c63430008150033",
+}
+
 // SafeMathABI is the input ABI used to generate the binding from.
-const SafeMathABI = "[]"
+// Deprecated: Use SafeMathMetaData.ABI instead.
+var SafeMathABI = SafeMathMetaData.ABI
 
 // SafeMathBin is the compiled bytecode used for deploying new contracts.
-var SafeMathBin = "0x60566023600b82828239805160001a607314601657fe5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea2646970667358221220711fae5256c1fee8fbc215255c19ada9220a069445547ec5b42ec69d74584b4164736f6c63430007060033"
+// Deprecated: Use SafeMathMetaData.Bin instead.
+var SafeMathBin = SafeMathMetaData.Bin
 
 // DeploySafeMath deploys a new Ethereum contract, binding an instance of SafeMath to it.
 func DeploySafeMath(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *SafeMath, error) {
-	parsed, err := abi.JSON(strings.NewReader(SafeMathABI))
+	parsed, err := SafeMathMetaData.GetAbi()
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
 
-	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(SafeMathBin), backend)
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(SafeMathBin), backend)
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
@@ -1244,20 +1322,31 @@ func (_SafeMath *SafeMathTransactorRaw) Transact(opts *bind.TransactOpts, method
 	return _SafeMath.Contract.contract.Transact(opts, method, params...)
 }
 
+// SigMetaData contains all meta data concerning the Sig contract.
+var SigMetaData = &bind.MetaData{
+	ABI: "[]",
+	Bin: "0x60566037600b82828239805160001a607314602a57634e487b7160e01b600052600060045260246000fd5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea26469706673582212208d53955800acc0036a92ded96cbb8b10972b523951f06514663ffe192285430564736f6c63430008150033",
+}
+
 // SigABI is the input ABI used to generate the binding from.
-const SigABI = "[]"
+// Deprecated: Use SigMetaData.ABI instead.
+var SigABI = SigMetaData.ABI
 
 // SigBin is the compiled bytecode used for deploying new contracts.
-var SigBin = "0x60566023600b82828239805160001a607314601657fe5b30600052607381538281f3fe73000000000000000000000000000000000000000030146080604052600080fdfea26469706673582212201718b7e8ea73e0a79b0b33b8b17bcb8b4cb4240ba977ae47a506fe8a5075556e64736f6c63430007060033"
+// Deprecated: Use SigMetaData.Bin instead.
+var SigBin = SigMetaData.Bin
 
 // DeploySig deploys a new Ethereum contract, binding an instance of Sig to it.
 func DeploySig(auth *bind.TransactOpts, backend bind.ContractBackend) (common.Address, *types.Transaction, *Sig, error) {
-	parsed, err := abi.JSON(strings.NewReader(SigABI))
+	parsed, err := SigMetaData.GetAbi()
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
+	if parsed == nil {
+		return common.Address{}, nil, nil, errors.New("GetABI returned nil")
+	}
 
-	address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(SigBin), backend)
+	address, tx, contract, err := bind.DeployContract(auth, *parsed, common.FromHex(SigBin), backend)
 	if err != nil {
 		return common.Address{}, nil, nil, err
 	}
@@ -1405,3 +1494,4 @@ func (_Sig *SigTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transact
 func (_Sig *SigTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
 	return _Sig.Contract.contract.Transact(opts, method, params...)
 }
+
@@ -1,7 +1,6 @@
 // SPDX-License-Identifier: Apache-2.0
 
-pragma solidity ^0.7.0;
-pragma experimental ABIEncoderV2;
+pragma solidity ^0.8.0;
 
 import "./perun-eth-contracts/contracts/App.sol";
 import "./perun-eth-contracts/contracts/Channel.sol";
@@ -13,27 +12,64 @@ import "./Decode.sol";
  * CredentialSwap is a channel app for swapping a credential against a payment.
  */
 contract CredentialSwap is App {
-    enum Mode{ Default, Offer, Cert }
-    uint8 constant ASSET_INDEX = 0;
+    enum Mode{ Default, Pending, Cert, Quote }
     // Indices corresponding to data encoding.
     uint8 constant MODE_INDEX = 0;
     uint8 constant SIG_INDEX = 0;
     uint8 constant SIG_LENGTH = 65;
 
+    // Signature schemes for issued credentials.
+    uint8 constant SCHEME_HASH = 0;
+    uint8 constant SCHEME_EIP712 = 1;
+
+    bytes32 constant EIP712_DOMAIN_TYPEHASH = keccak256(
+        "EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)");
+    bytes32 constant CREDENTIAL_TYPEHASH = keccak256(
+        "Credential(bytes32 docHash,bytes32 channelId,address holder,address issuer,uint256 chainId,address contract)");
+    bytes32 constant DELEGATION_TYPEHASH = keccak256(
+        "Delegation(address issuer,address delegate,uint64 notBefore,uint64 notAfter)");
+
     struct Frame {
         uint8 mode;
         bytes body;
     }
 
     struct Offer {
-        address issuer;
-        bytes32 h;
-        uint256 price;
+        uint64 id;
+        address[] issuers;
+        uint16 threshold;
+        bytes32[] hashes;
+        uint256[] prices;
+        uint16 asset;
         uint16 buyer;
+        uint64 expiry;
+        uint64 createdAt;
+        uint8 scheme;
+        uint256 chainId;
+    }
+
+    /// Delegation authorizes `delegate` to sign on behalf of an issuer for
+    /// offers created from `notBefore` until `notAfter`, in Unix seconds. The
+    /// zero delegate means that the issuer signs directly.
+    struct Delegation {
+        address delegate;
+        uint64 notBefore;
+        uint64 notAfter;
+        bytes sig;
     }
 
     struct Cert {
+        uint64 id;
+        uint16[] signers;
+        Delegation[] delegations;
+        bytes[] sigs;
+        Offer[] pending;
+    }
+
+    struct Quote {
+        Offer offer;
         bytes sig;
+        Offer[] pending;
     }
 
     /**
@@ -45,56 +81,234 @@ contract CredentialSwap is App {
      * @param actor The index of the actor.
      */
     function validTransition(
-        Channel.Params calldata /*params*/,
+        Channel.Params calldata params,
         Channel.State calldata cur,
         Channel.State calldata next,
         uint256 actor
     ) external pure override {
-        // We require that we only have a constant single asset.
-        requireConstantSingleAsset(cur, next);
-
-        // Decode current state.
-        Frame memory frame = decodeFrame(cur);
-        if (frame.mode == uint8(Mode.Offer)) {
-            Offer memory offer = decodeOffer(frame.body);
-            validTransitionFromOffer(offer, cur, next, actor);
-        } else {
-            // We require that the balances did not change.
-            requireBalancesUnchanged(cur, next);
-
-            // If the next state is an offer, check that the potential buyer has
-            // sufficient funds to fulfill the payment.
-            Frame memory nextFrame = decodeFrame(next);
-            if (nextFrame.mode == uint8(Mode.Offer)) {
-                Offer memory offer = decodeOffer(nextFrame.body);
-                uint256[][] calldata nextBals = next.outcome.balances;
-                require(nextBals[ASSET_INDEX][offer.buyer] >= offer.price,
-                    "insufficient funds");
+        // We require that the assets do not change.
+        requireConstantAssets(cur, next);
+
+        Frame memory curFrame = decodeFrame(cur);
+        Frame memory nextFrame = decodeFrame(next);
+        Offer[] memory curOffers = pendingOffers(curFrame);
+
+        if (nextFrame.mode == uint8(Mode.Cert)) {
+            Cert memory cert = decodeCert(nextFrame.body);
+            validCert(params, curOffers, cert, cur, next, actor);
+            return;
+        }
+
+        // We require that the balances did not change.
+        requireBalancesUnchanged(cur, next);
+
+        Offer[] memory nextOffers = pendingOffers(nextFrame);
+        validOffersTransition(params, curOffers, nextOffers, next, actor);
+
+        // If the next state holds a new quote, check that it is a valid offer
+        // signed by the first issuer.
+        if (nextFrame.mode == uint8(Mode.Quote)) {
+            Quote memory quote = decodeQuote(nextFrame.body);
+            bool known = false;
+            if (curFrame.mode == uint8(Mode.Quote)) {
+                Quote memory curQuote = decodeQuote(curFrame.body);
+                known = hashOffer(curQuote.offer) == hashOffer(quote.offer) &&
+                    keccak256(curQuote.sig) == keccak256(quote.sig);
+            }
+            if (!known) {
+                requireValidOffer(params, next, quote.offer);
+                require(verify(hashOffer(quote.offer), quote.sig, quote.offer.issuers[0]),
+                    "invalid quote signature");
             }
         }
     }
 
-    function validTransitionFromOffer(
-        Offer memory offer,
+    /**
+     * validOffersTransition checks the changes to the pending offers. Offers
+     * that remain pending must not change. An offer can be removed by the
+     * issuer at any time and by the buyer once it expired. Offers can only be
+     * added by their buyer, who must have sufficient funds for all pending
+     * offers. The buyer of a pending offer cannot advance the version without
+     * changing the offers, and never to the expiry of the offer, as it could
+     * otherwise let the offer expire before the issuer answers.
+     */
+    function validOffersTransition(
+        Channel.Params calldata params,
+        Offer[] memory curOffers,
+        Offer[] memory nextOffers,
+        Channel.State calldata next,
+        uint256 actor
+    ) internal pure {
+        bool changed = false;
+        for (uint i = 0; i < curOffers.length; i++) {
+            Offer memory offer = curOffers[i];
+            (uint j, bool ok) = findOffer(nextOffers, offer.id);
+            if (!ok) {
+                // The offer is cancelled.
+                require(actor != offer.buyer || next.version > offer.expiry, "offer not expired");
+                changed = true;
+            } else {
+                require(hashOffer(nextOffers[j]) == hashOffer(offer), "invalid next offer");
+            }
+        }
+
+        for (uint i = 0; i < nextOffers.length; i++) {
+            Offer memory offer = nextOffers[i];
+            (uint j, ) = findOffer(nextOffers, offer.id);
+            require(j == i, "duplicate offer");
+            (, bool ok) = findOffer(curOffers, offer.id);
+            if (!ok) {
+                // The offer is new.
+                requireValidOffer(params, next, offer);
+                require(actor == offer.buyer, "invalid buyer");
+                changed = true;
+            }
+        }
+
+        if (!changed) {
+            for (uint i = 0; i < curOffers.length; i++) {
+                require(actor != curOffers[i].buyer, "offer pending");
+            }
+        }
+        for (uint i = 0; i < nextOffers.length; i++) {
+            require(actor != nextOffers[i].buyer || next.version < nextOffers[i].expiry, "offer pending");
+        }
+
+        requireSufficientFunds(next, nextOffers);
+    }
+
+    /**
+     * validCert checks that the certificate answers a pending offer with valid
+     * signatures and that the payment is transferred from the buyer to the
+     * issuer.
+     */
+    function validCert(
+        Channel.Params calldata params,
+        Offer[] memory curOffers,
+        Cert memory cert,
         Channel.State calldata cur,
         Channel.State calldata next,
         uint256 actor
     ) internal pure {
-        // Decode next state.
-        (Cert memory cert, bool ok) = decodeCert(next);
-        require(ok, "invalid next mode");
+        (uint idx, bool ok) = findOffer(curOffers, cert.id);
+        require(ok, "unknown offer");
+        Offer memory offer = curOffers[idx];
         uint256 seller = actor;
 
-        // Verify signature.
-        require(verify(offer.h, cert.sig, offer.issuer), "invalid signature");
+        // The other offers remain pending.
+        require(cert.pending.length + 1 == curOffers.length, "invalid pending offers");
+        for (uint i = 0; i < cert.pending.length; i++) {
+            uint j = i < idx ? i : i + 1;
+            require(hashOffer(cert.pending[i]) == hashOffer(curOffers[j]), "invalid pending offers");
+        }
+
+        // The issuer can only claim the payment until the offer expires.
+        require(next.version <= offer.expiry, "offer expired");
+
+        requireValidSignatures(params, offer, cert, next.channelID);
 
-        // Verify balances.
+        // Verify balances. The buyer's balance is checked explicitly, as the
+        // subtraction would otherwise revert without a reason.
+        uint256 price = totalPrice(offer);
         uint256[][] calldata curBals = cur.outcome.balances;
         uint256[][] calldata nextBals = next.outcome.balances;
-        require(nextBals[ASSET_INDEX][offer.buyer] == curBals[ASSET_INDEX][offer.buyer] - offer.price,
+        require(curBals[offer.asset][offer.buyer] >= price, "insufficient funds");
+        require(nextBals[offer.asset][offer.buyer] == curBals[offer.asset][offer.buyer] - price,
             "invalid amount transferred: buyer");
-        require(nextBals[ASSET_INDEX][seller] == curBals[ASSET_INDEX][seller] + offer.price,
+        require(nextBals[offer.asset][seller] == curBals[offer.asset][seller] + price,
             "invalid amount transferred: seller");
+
+        // Verify that the balances of the other assets did not change.
+        for (uint i = 0; i < curBals.length; i++) {
+            if (i != offer.asset) {
+                Array.requireEqualUint256Array(curBals[i], nextBals[i]);
+            }
+        }
+    }
+
+    /**
+     * requireValidSignatures checks the signatures of the certificate on the
+     * documents of the offer.
+     */
+    function requireValidSignatures(
+        Channel.Params calldata params,
+        Offer memory offer,
+        Cert memory cert,
+        bytes32 channelID
+    ) internal pure {
+        // Verify signatures. We require a valid signature on each document by
+        // at least `threshold` distinct issuers. The signers are given in
+        // ascending order, which ensures that they are distinct.
+        uint n = cert.signers.length;
+        require(n >= offer.threshold, "threshold not met");
+        for (uint j = 0; j < n; j++) {
+            require(cert.signers[j] < offer.issuers.length, "invalid signer");
+            require(j == 0 || cert.signers[j] > cert.signers[j-1], "invalid signer");
+        }
+        require(cert.delegations.length == n, "invalid number of delegations");
+        require(cert.sigs.length == offer.hashes.length * n, "invalid number of signatures");
+        address[] memory keys = new address[](n);
+        for (uint j = 0; j < n; j++) {
+            keys[j] = signingKey(offer, offer.issuers[cert.signers[j]], cert.delegations[j], params.app);
+        }
+        address holder = params.participants[offer.buyer];
+        for (uint i = 0; i < offer.hashes.length; i++) {
+            for (uint j = 0; j < n; j++) {
+                bytes32 h = credentialHash(
+                    offer, offer.hashes[i], offer.issuers[cert.signers[j]], channelID, holder, params.app);
+                require(verify(h, cert.sigs[i * n + j], keys[j]), "invalid signature");
+            }
+        }
+    }
+
+    function requireValidOffer(
+        Channel.Params calldata params,
+        Channel.State calldata next,
+        Offer memory offer
+    ) internal pure {
+        require(offer.hashes.length > 0, "invalid offer: no documents");
+        require(offer.hashes.length == offer.prices.length, "invalid offer: unequal length");
+        for (uint i = 0; i < offer.prices.length; i++) {
+            require(offer.prices[i] > 0, "invalid offer: zero price");
+        }
+        require(offer.threshold > 0 && offer.threshold <= offer.issuers.length, "invalid threshold");
+        for (uint i = 0; i < offer.issuers.length; i++) {
+            for (uint j = 0; j < i; j++) {
+                require(offer.issuers[i] != offer.issuers[j], "duplicate issuer");
+            }
+        }
+        require(offer.asset < next.outcome.assets.length, "invalid asset");
+        require(offer.buyer < params.participants.length, "invalid buyer");
+        require(offer.expiry > next.version, "offer expired");
+        require(offer.scheme == SCHEME_HASH || offer.scheme == SCHEME_EIP712, "invalid signature scheme");
+    }
+
+    /// requireSufficientFunds checks that each buyer has sufficient funds to
+    /// pay for all of their pending offers.
+    function requireSufficientFunds(Channel.State calldata s, Offer[] memory offers) internal pure {
+        uint256[][] calldata bals = s.outcome.balances;
+        for (uint i = 0; i < offers.length; i++) {
+            uint256 sum = 0;
+            for (uint j = 0; j < offers.length; j++) {
+                if (offers[j].asset == offers[i].asset && offers[j].buyer == offers[i].buyer) {
+                    sum += totalPrice(offers[j]);
+                }
+            }
+            require(bals[offers[i].asset][offers[i].buyer] >= sum, "insufficient funds");
+        }
+    }
+
+    function findOffer(Offer[] memory offers, uint64 id) internal pure returns (uint, bool) {
+        for (uint i = 0; i < offers.length; i++) {
+            if (offers[i].id == id) {
+                return (i, true);
+            }
+        }
+        return (0, false);
+    }
+
+    function hashOffer(Offer memory offer) internal pure returns (bytes32) {
+        return keccak256(abi.encode(offer));
     }
 
     function decodeFrame(Channel.State calldata s) internal pure returns (Frame memory) {
@@ -105,18 +319,95 @@ contract CredentialSwap is App {
         return frame;
     }
 
-    function decodeOffer(bytes memory data) internal pure returns (Offer memory) {
-        (Offer memory offer) = abi.decode(data, (Offer));
-        return offer;
+    /// pendingOffers returns the pending offers of the given frame.
+    function pendingOffers(Frame memory frame) internal pure returns (Offer[] memory) {
+        if (frame.mode == uint8(Mode.Pending)) {
+            return abi.decode(frame.body, (Offer[]));
+        } else if (frame.mode == uint8(Mode.Cert)) {
+            return decodeCert(frame.body).pending;
+        } else if (frame.mode == uint8(Mode.Quote)) {
+            return decodeQuote(frame.body).pending;
+        }
+        return new Offer[](0);
+    }
+
+    function decodeCert(bytes memory body) internal pure returns (Cert memory) {
+        (uint64 id, uint16[] memory signers, Delegation[] memory delegations, bytes[] memory sigs, Offer[] memory pending) =
+            abi.decode(body, (uint64, uint16[], Delegation[], bytes[], Offer[]));
+        return Cert({id: id, signers: signers, delegations: delegations, sigs: sigs, pending: pending});
+    }
+
+    function decodeQuote(bytes memory body) internal pure returns (Quote memory) {
+        (Offer memory offer, bytes memory sig, Offer[] memory pending) = abi.decode(body, (Offer, bytes, Offer[]));
+        return Quote({offer: offer, sig: sig, pending: pending});
+    }
+
+    /// totalPrice returns the sum of the prices of the offered documents. It
+    /// reverts if the sum overflows.
+    function totalPrice(Offer memory offer) internal pure returns (uint256 sum) {
+        for (uint i = 0; i < offer.prices.length; i++) {
+            sum += offer.prices[i];
+        }
     }
 
-    function decodeCert(Channel.State calldata state) internal pure returns (Cert memory, bool) {
-        Frame memory s = decodeFrame(state);
-        if (s.mode != uint8(Mode.Cert)) {
-            return (Cert(''), false);
+    /// credentialHash returns the message that `issuer` signs for the
+    /// document with hash `docHash`. The message is bound to the channel, the
+    /// holder and the app contract. In the EIP-712 scheme, the app contract is
+    /// the verifying contract of the signing domain.
+    function credentialHash(
+        Offer memory offer,
+        bytes32 docHash,
+        address issuer,
+        bytes32 channelID,
+        address holder,
+        address app
+    ) internal pure returns (bytes32) {
+        if (offer.scheme == SCHEME_HASH) {
+            return keccak256(abi.encode(docHash, channelID, holder, app));
         }
+        bytes32 structHash = keccak256(abi.encode(
+            CREDENTIAL_TYPEHASH,
+            docHash,
+            channelID,
+            holder,
+            issuer,
+            offer.chainId,
+            app
+        ));
+        return keccak256(abi.encodePacked("\x19\x01", domainSeparator(offer.chainId, app), structHash));
+    }
+
+    /// domainSeparator returns the EIP-712 domain separator for the given
+    /// chain and app contract.
+    function domainSeparator(uint256 chainId, address app) internal pure returns (bytes32) {
+        return keccak256(abi.encode(
+            EIP712_DOMAIN_TYPEHASH,
+            keccak256("CredentialSwap"),
+            keccak256("1"),
+            chainId,
+            app
+        ));
+    }
 
-        return (Cert({sig: s.body}), true);
+    /// signingKey returns the key that signs on behalf of `issuer` for the
+    /// offer. It is the delegate if the delegation is not zero and the issuer
+    /// otherwise. The delegation is signed in the EIP-712 domain of the offer
+    /// and must be valid at the creation time of the offer.
+    function signingKey(
+        Offer memory offer,
+        address issuer,
+        Delegation memory d,
+        address app
+    ) internal pure returns (address) {
+        if (d.delegate == address(0)) {
+            return issuer;
+        }
+        bytes32 structHash = keccak256(abi.encode(
+            DELEGATION_TYPEHASH, issuer, d.delegate, d.notBefore, d.notAfter));
+        bytes32 h = keccak256(abi.encodePacked("\x19\x01", domainSeparator(offer.chainId, app), structHash));
+        require(verify(h, d.sig, issuer), "invalid delegation");
+        require(d.notBefore <= offer.createdAt && offer.createdAt <= d.notAfter, "delegation not valid");
+        return d.delegate;
     }
 
     /// verify verifies that `sig` is a signature on `h` by `signer`.
@@ -125,14 +416,15 @@ contract CredentialSwap is App {
         return recoveredAddr == signer;
     }
 
-    function requireConstantSingleAsset(Channel.State calldata cur, Channel.State calldata next) internal pure {
+    function requireConstantAssets(Channel.State calldata cur, Channel.State calldata next) internal pure {
         (address[] memory a1, address[] memory a2) = (cur.outcome.assets, next.outcome.assets);
-        
-        uint8 numAssets = 1;
-        require(a1.length == numAssets, "invalid number of assets: current");
-        require(a2.length == numAssets, "invalid number of assets: next");
 
-        require(a1[ASSET_INDEX] == a2[ASSET_INDEX], "invalid asset");
+        require(a1.length > 0, "invalid number of assets: current");
+        require(a1.length == a2.length, "invalid number of assets: next");
+
+        for (uint i = 0; i < a1.length; i++) {
+            require(a1[i] == a2[i], "invalid asset");
+        }
     }
 
     function requireBalancesUnchanged(Channel.State calldata cur, Channel.State calldata next) internal pure {
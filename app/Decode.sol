@@ -1,6 +1,6 @@
 // SPDX-License-Identifier: Apache-2.0
 
-pragma solidity ^0.7.0;
+pragma solidity ^0.8.0;
 
 library Decode {
     function toUint16(bytes memory _bytes, uint256 _start) internal pure returns (uint16) {
@@ -45,7 +45,9 @@ library Decode {
         pure
         returns (bytes memory)
     {
-        require(_length + 31 >= _length, "slice_overflow");
+        unchecked {
+            require(_length + 31 >= _length, "slice_overflow");
+        }
         require(_bytes.length >= _start + _length, "slice_outOfBounds");
 
         bytes memory tempBytes;
@@ -5,12 +5,15 @@ import (
 )
 
 var (
-	Address = createType("address")
-	Bytes32 = createType("bytes32")
-	Bytes   = createType("bytes")
-	Uint8   = createType("uint8")
-	Uint16  = createType("uint16")
-	Uint256 = createType("uint256")
+	Address     = createType("address")
+	Bytes32     = createType("bytes32")
+	Bytes       = createType("bytes")
+	BytesArray  = createType("bytes[]")
+	Uint8       = createType("uint8")
+	Uint16      = createType("uint16")
+	Uint16Array = createType("uint16[]")
+	Uint64      = createType("uint64")
+	Uint256     = createType("uint256")
 )
 
 func createType(name string) abi.Type {
@@ -15,6 +15,7 @@
 package app
 
 import (
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"io"
@@ -22,14 +23,11 @@ import (
 
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/perun-network/perun-credential-payment/app/data"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/wallet"
 )
 
-const (
-	AssetIdx = 0
-)
-
 var (
 	ErrInvalidInitData     = errors.New("invalid init data")
 	ErrInvalidNextData     = errors.New("invalid next data")
@@ -37,6 +35,16 @@ var (
 	ErrUnequalAllocation   = errors.New("unequal allocation")
 	ErrInsufficientBalance = errors.New("insufficient balance")
 	ErrInvalidSigner       = errors.New("invalid signer")
+	ErrInvalidAsset        = errors.New("invalid asset")
+	ErrInvalidBuyer        = errors.New("invalid buyer")
+	ErrOfferExpired        = errors.New("offer expired")
+	ErrOfferNotExpired     = errors.New("offer not expired")
+	ErrOfferPending        = errors.New("offer pending")
+	ErrInvalidOffer        = errors.New("invalid offer")
+	ErrInvalidScheme       = errors.New("invalid signature scheme")
+	ErrInvalidHolder       = errors.New("invalid holder")
+	ErrThresholdNotMet     = errors.New("signature threshold not met")
+	ErrDelegationNotValid  = errors.New("delegation not valid")
 )
 
 // CredentialSwapApp is a channel app for atomically trading a credential against a payment.
@@ -75,82 +83,280 @@ func (a *CredentialSwapApp) ValidInit(p *channel.Params, s *channel.State) error
 
 // ValidTransition is called whenever the channel state transitions.
 func (a *CredentialSwapApp) ValidTransition(params *channel.Params, cur, next *channel.State, actorIdx channel.Index) error {
-	// We require that there is only a single constant asset.
-	if err := assertSingleConstantAsset(cur, next); err != nil {
+	// We require that the assets do not change.
+	if err := assertConstantAssets(cur, next); err != nil {
 		return err
 	}
 
-	switch cur.Data.(type) {
-	case *data.Offer:
-		err := validTransitionFromOffer(cur, next, actorIdx)
+	curOffers := data.PendingOffers(cur.Data)
+	if cert, ok := next.Data.(*data.Cert); ok {
+		err := validCert(params, curOffers, cur, next, cert, actorIdx)
 		if err != nil {
-			return fmt.Errorf("validating transition from offer: %w", err)
+			return fmt.Errorf("validating cert: %w", err)
 		}
+		return nil
+	}
+
+	// We require that the balances did not change.
+	if !cur.Balances.Equal(next.Balances) {
+		return fmt.Errorf("unequal balances")
+	}
 
-	default:
-		// We require that the balances did not change.
-		if !cur.Balances.Equal(next.Balances) {
-			return fmt.Errorf("unequal balances")
+	nextOffers := data.PendingOffers(next.Data)
+	if err := validOffersTransition(params, curOffers, nextOffers, next, actorIdx); err != nil {
+		return err
+	}
+
+	// If the next state holds a new quote, check that it is a valid offer
+	// signed by the issuer.
+	if quote, ok := next.Data.(*data.Quote); ok && !sameQuote(cur.Data, quote) {
+		offer := &quote.Offer
+		if err := assertValidOffer(params, next, offer); err != nil {
+			return err
+		}
+		h, err := offer.Hash()
+		if err != nil {
+			return fmt.Errorf("hashing quote: %w", err)
 		}
+		if err := VerifySig(quote.Signature, h, offer.Issuers[0]); err != nil {
+			return fmt.Errorf("verifying quote signature: %w", err)
+		}
+	}
+
+	return nil
+}
 
-		// If the next state is an offer, check that there is sufficient funds
-		// to fulfill the payment.
-		if offer, ok := next.Data.(*data.Offer); ok {
-			if next.Balances[AssetIdx][offer.Buyer].Cmp(offer.Price) < 0 {
-				return fmt.Errorf("insufficient funds")
+// validOffersTransition checks the changes to the pending offers. Offers that
+// remain pending must not change. An offer can be removed by the issuer at
+// any time and by the buyer once it expired. Offers can only be added by
+// their buyer, who must have sufficient funds for all pending offers. The
+// buyer of a pending offer cannot advance the version without changing the
+// offers, and never to the expiry of the offer, as it could otherwise let the
+// offer expire before the issuer answers.
+func validOffersTransition(params *channel.Params, curOffers, nextOffers []data.Offer, next *channel.State, actorIdx channel.Index) error {
+	changed := false
+	for i := range curOffers {
+		offer := &curOffers[i]
+		j, ok := data.FindOffer(nextOffers, offer.ID)
+		if !ok {
+			// The offer is cancelled.
+			if actorIdx == channel.Index(offer.Buyer) && next.Version <= offer.Expiry {
+				return ErrOfferNotExpired
 			}
+			changed = true
+		} else if !nextOffers[j].Equal(offer) {
+			return fmt.Errorf("offer %d changed: %w", offer.ID, ErrInvalidNextData)
 		}
 	}
 
-	return nil
+	for i := range nextOffers {
+		offer := &nextOffers[i]
+		if j, _ := data.FindOffer(nextOffers, offer.ID); j != i {
+			return fmt.Errorf("duplicate offer %d: %w", offer.ID, ErrInvalidOffer)
+		}
+		if _, ok := data.FindOffer(curOffers, offer.ID); ok {
+			continue
+		}
+
+		// The offer is new.
+		if err := assertValidOffer(params, next, offer); err != nil {
+			return err
+		} else if actorIdx != channel.Index(offer.Buyer) {
+			return ErrInvalidBuyer
+		}
+		changed = true
+	}
+
+	if !changed {
+		for _, offer := range curOffers {
+			if actorIdx == channel.Index(offer.Buyer) {
+				return fmt.Errorf("offer %d: %w", offer.ID, ErrOfferPending)
+			}
+		}
+	}
+	for _, offer := range nextOffers {
+		if actorIdx == channel.Index(offer.Buyer) && next.Version >= offer.Expiry {
+			return fmt.Errorf("offer %d expires: %w", offer.ID, ErrOfferPending)
+		}
+	}
+
+	return assertSufficientFunds(next, nextOffers)
 }
 
-func validTransitionFromOffer(cur *channel.State, next *channel.State, actorIdx channel.Index) error {
-	offer := cur.Data.(*data.Offer)
+// sameQuote returns whether d is a quote equal to q.
+func sameQuote(d channel.Data, q *data.Quote) bool {
+	cur, ok := d.(*data.Quote)
+	return ok && cur.Offer.Equal(&q.Offer) && cur.Signature == q.Signature
+}
 
-	// Verify signature.
-	{
-		// Decode next state.
-		cert, ok := next.Data.(*data.Cert)
-		if !ok {
-			return ErrInvalidNextData
+// validCert checks that the certificate answers a pending offer with valid
+// signatures and that the payment is transferred from the buyer to the issuer.
+func validCert(params *channel.Params, curOffers []data.Offer, cur, next *channel.State, cert *data.Cert, actorIdx channel.Index) error {
+	i, ok := data.FindOffer(curOffers, cert.ID)
+	if !ok {
+		return ErrExpectedOffer
+	}
+	offer := &curOffers[i]
+
+	// The other offers remain pending.
+	rest := data.RemoveOffer(curOffers, i)
+	if len(rest) != len(cert.Pending) {
+		return fmt.Errorf("wrong pending offers")
+	}
+	for j := range rest {
+		if !rest[j].Equal(&cert.Pending[j]) {
+			return fmt.Errorf("wrong pending offers")
 		}
+	}
 
-		err := VerifySig(cert.Signature, offer.DataHash, offer.Issuer)
-		if err != nil {
-			return fmt.Errorf("verifying signature: %w", err)
+	// The issuer can only claim the payment until the offer expires.
+	if next.Version > offer.Expiry {
+		return ErrOfferExpired
+	}
+
+	// Verify signatures. We require a valid signature on each document by at
+	// least Threshold distinct issuers.
+	if err := assertValidSigners(offer, cert); err != nil {
+		return err
+	}
+	b := Binding{
+		ChannelID: next.ID,
+		Holder:    ethwallet.AsEthAddr(params.Parts[offer.Buyer]),
+		Contract:  ethwallet.AsEthAddr(params.App.Def()),
+	}
+	for i, docHash := range offer.DataHashes {
+		for j, signer := range cert.Signers {
+			issuer := offer.Issuers[signer]
+			key, err := SigningKey(issuer, &cert.Delegations[j], Domain{offer.ChainID, b.Contract}, offer.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("issuer %d: %w", signer, err)
+			}
+			h, err := CredentialHash(offer, docHash, issuer, b)
+			if err != nil {
+				return fmt.Errorf("computing credential hash %d: %w", i, err)
+			}
+			err = VerifySig(cert.Signature(i, j), h, key)
+			if err != nil {
+				return fmt.Errorf("verifying signature %d of issuer %d: %w", i, signer, err)
+			}
 		}
 	}
 
 	// Verify balances.
+	price := offer.TotalPrice()
 
 	// Verify buyer balance.
 	{
-		expectedBal := new(big.Int).Sub(cur.Balances[AssetIdx][offer.Buyer], offer.Price)
-		if next.Balances[AssetIdx][offer.Buyer].Cmp(expectedBal) != 0 {
+		expectedBal := new(big.Int).Sub(cur.Balances[offer.Asset][offer.Buyer], price)
+		if next.Balances[offer.Asset][offer.Buyer].Cmp(expectedBal) != 0 {
 			return fmt.Errorf("wrong balance: buyer")
 		}
 	}
 
 	// Verify seller balance.
 	{
-		expectedBal := new(big.Int).Add(cur.Balances[AssetIdx][actorIdx], offer.Price)
-		if next.Balances[AssetIdx][actorIdx].Cmp(expectedBal) != 0 {
+		expectedBal := new(big.Int).Add(cur.Balances[offer.Asset][actorIdx], price)
+		if next.Balances[offer.Asset][actorIdx].Cmp(expectedBal) != 0 {
 			return fmt.Errorf("wrong balance: seller")
 		}
 	}
 
+	// Verify that the balances of the other assets did not change.
+	for i := range cur.Balances {
+		if i == int(offer.Asset) {
+			continue
+		}
+		if !cur.Balances[i : i+1].Equal(next.Balances[i : i+1]) {
+			return fmt.Errorf("wrong balance: asset %d", i)
+		}
+	}
+
+	return nil
+}
+
+// assertValidSigners checks that the certificate is signed by at least
+// Threshold distinct issuers of the offer and contains a delegation for each
+// signer and a signature by each signer on each document.
+func assertValidSigners(offer *data.Offer, cert *data.Cert) error {
+	if len(cert.Signers) < int(offer.Threshold) {
+		return ErrThresholdNotMet
+	}
+	for j, signer := range cert.Signers {
+		if int(signer) >= len(offer.Issuers) {
+			return ErrInvalidSigner
+		} else if j > 0 && signer <= cert.Signers[j-1] {
+			// Ascending order ensures that the signers are distinct.
+			return ErrInvalidSigner
+		}
+	}
+	if len(cert.Delegations) != len(cert.Signers) {
+		return fmt.Errorf("wrong number of delegations")
+	}
+	if len(cert.Signatures) != len(offer.DataHashes)*len(cert.Signers) {
+		return fmt.Errorf("wrong number of signatures")
+	}
 	return nil
 }
 
-func assertSingleConstantAsset(cur, next *channel.State) error {
-	const numAssets = 1
-	if len(cur.Allocation.Assets) != numAssets {
-		return fmt.Errorf("wrong number of assets: current state")
-	} else if len(next.Allocation.Assets) != numAssets {
-		return fmt.Errorf("wrong number of assets: next state")
+// assertValidOffer checks that the offer contains at least one document with
+// a positive price, distinct issuers with a satisfiable threshold and a known signature
+// scheme, that the asset and buyer indices of the offer are within the bounds
+// of the channel, and that the issuers are able to answer the offer.
+func assertValidOffer(params *channel.Params, s *channel.State, offer *data.Offer) error {
+	if len(offer.DataHashes) == 0 || len(offer.DataHashes) != len(offer.Prices) {
+		return ErrInvalidOffer
+	}
+	if offer.Threshold == 0 || int(offer.Threshold) > len(offer.Issuers) {
+		return ErrInvalidOffer
+	}
+	for i := range offer.Issuers {
+		for j := 0; j < i; j++ {
+			if offer.Issuers[i] == offer.Issuers[j] {
+				return ErrInvalidOffer
+			}
+		}
+	}
+	for _, p := range offer.Prices {
+		if p.Sign() <= 0 {
+			return ErrInvalidOffer
+		}
+	}
+	if offer.Scheme != SchemeHash && offer.Scheme != SchemeEIP712 {
+		return ErrInvalidScheme
+	} else if offer.ChainID == nil {
+		return ErrInvalidOffer
+	} else if int(offer.Asset) >= len(s.Assets) {
+		return ErrInvalidAsset
+	} else if int(offer.Buyer) >= len(params.Parts) {
+		return ErrInvalidBuyer
+	} else if offer.Expiry <= s.Version {
+		return ErrOfferExpired
+	}
+	return nil
+}
+
+// assertSufficientFunds checks that each buyer has sufficient funds to pay for
+// all of their pending offers.
+func assertSufficientFunds(s *channel.State, offers []data.Offer) error {
+	for i := range offers {
+		sum := new(big.Int)
+		for j := range offers {
+			if offers[j].Asset == offers[i].Asset && offers[j].Buyer == offers[i].Buyer {
+				sum.Add(sum, offers[j].TotalPrice())
+			}
+		}
+		if s.Balances[offers[i].Asset][offers[i].Buyer].Cmp(sum) < 0 {
+			return ErrInsufficientBalance
+		}
+	}
+	return nil
+}
+
+func assertConstantAssets(cur, next *channel.State) error {
+	if len(cur.Allocation.Assets) == 0 {
+		return fmt.Errorf("no assets: current state")
 	} else if err := channel.AssetsAssertEqual(cur.Assets, next.Assets); err != nil {
-		return fmt.Errorf("asset not equal: %w", err)
+		return fmt.Errorf("assets not equal: %w", err)
 	}
 	return nil
 }
@@ -164,11 +370,21 @@ func ComputeDocumentHash(doc []byte) Hash {
 	return crypto.Keccak256Hash(doc)
 }
 
-type Credential struct {
-	Document  []byte
-	Signature []byte
+// ComputeSaltedDocumentHash returns the commitment keccak(doc || salt) to the
+// given document. With a random salt, the commitment reveals nothing about the
+// document. An empty salt yields the plain document hash.
+func ComputeSaltedDocumentHash(doc, salt []byte) Hash {
+	return crypto.Keccak256Hash(doc, salt)
 }
 
-func (c *Credential) String() string {
-	return fmt.Sprintf("Document: \"%s\" Signature: \"%x\"", c.Document, c.Signature)
+// SaltLen is the length of the salts returned by NewSalt.
+const SaltLen = 32
+
+// NewSalt returns a random salt for a document commitment.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("reading randomness: %w", err)
+	}
+	return salt, nil
 }
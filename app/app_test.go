@@ -0,0 +1,647 @@
+package app_test
+
+import (
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"github.com/stretchr/testify/require"
+	_ "perun.network/go-perun/backend/ethereum" // Initialize backend.
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/backend/ethereum/wallet/simple"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/wallet"
+)
+
+const (
+	holderIdx = 0
+	issuerIdx = 1
+
+	offerExpiry = 10
+	chainID     = 1337
+)
+
+type setup struct {
+	app      *app.CredentialSwapApp
+	contract *app.CredentialSwapCaller
+	params   *channel.Params
+	issuer   *simple.Account
+}
+
+func newSetup(t *testing.T) *setup {
+	t.Helper()
+	require := require.New(t)
+
+	holderKey, err := crypto.GenerateKey()
+	require.NoError(err)
+	issuerKey, err := crypto.GenerateKey()
+	require.NoError(err)
+	issuerAddr := ethwallet.AsWalletAddr(crypto.PubkeyToAddress(issuerKey.PublicKey))
+	acc, err := simple.NewWallet(issuerKey).Unlock(issuerAddr)
+	require.NoError(err)
+
+	a := app.NewCredentialSwapApp(ethwallet.AsWalletAddr(common.Address{1}))
+	parts := []wallet.Address{
+		ethwallet.AsWalletAddr(crypto.PubkeyToAddress(holderKey.PublicKey)),
+		issuerAddr,
+	}
+	params := channel.NewParamsUnsafe(60, parts, a, big.NewInt(0), true, false)
+
+	backend, addr := deployCredentialSwap(t)
+	contract, err := app.NewCredentialSwapCaller(addr, backend)
+	require.NoError(err)
+
+	return &setup{app: a, contract: contract, params: params, issuer: acc.(*simple.Account)}
+}
+
+// newState returns a channel state with the given balances. The holder owns
+// the balances in `bals`, one entry per asset.
+func (s *setup) newState(d channel.Data, bals ...int64) *channel.State {
+	assets := make([]channel.Asset, len(bals))
+	for i := range assets {
+		assets[i] = ethwallet.AsWalletAddr(common.Address{byte(10 + i)})
+	}
+	alloc := channel.NewAllocation(len(s.params.Parts), assets...)
+	for i, b := range bals {
+		alloc.Balances[i][holderIdx] = big.NewInt(b)
+		alloc.Balances[i][issuerIdx] = big.NewInt(0)
+	}
+	return &channel.State{
+		ID:         s.params.ID(),
+		Version:    0,
+		App:        s.app,
+		Allocation: *alloc,
+		Data:       d,
+	}
+}
+
+func (s *setup) offer(h app.Hash, asset uint16, price int64) *data.Offer {
+	return &data.Offer{
+		ID:         1,
+		Issuers:    []common.Address{s.issuer.Account.Address},
+		Threshold:  1,
+		DataHashes: []app.Hash{h},
+		Prices:     []*big.Int{big.NewInt(price)},
+		Asset:      asset,
+		Buyer:      holderIdx,
+		Expiry:     offerExpiry,
+		ChainID:    big.NewInt(chainID),
+	}
+}
+
+func pending(offers ...*data.Offer) *data.Pending {
+	p := &data.Pending{}
+	for _, o := range offers {
+		p.Offers = append(p.Offers, *o)
+	}
+	return p
+}
+
+func (s *setup) binding() app.Binding {
+	return app.Binding{
+		ChannelID: s.params.ID(),
+		Holder:    ethwallet.AsEthAddr(s.params.Parts[holderIdx]),
+		Contract:  ethwallet.AsEthAddr(s.app.Def()),
+	}
+}
+
+// cert returns a certificate with signatures by the issuer on the given
+// documents in the given scheme.
+func (s *setup) cert(t *testing.T, id uint64, scheme app.SigScheme, hs ...app.Hash) *data.Cert {
+	t.Helper()
+	offer := &data.Offer{ID: id, DataHashes: hs, Scheme: scheme, ChainID: big.NewInt(chainID)}
+	return s.signCert(t, offer, map[uint16]*simple.Account{0: s.issuer})
+}
+
+// signCert returns a certificate for the given offer with signatures by the
+// given signers, which are indexed by their position in the issuers of the
+// offer.
+func (s *setup) signCert(t *testing.T, offer *data.Offer, signers map[uint16]*simple.Account) *data.Cert {
+	t.Helper()
+	cert := data.Cert{ID: offer.ID}
+	for j := range signers {
+		cert.Signers = append(cert.Signers, j)
+	}
+	sort.Slice(cert.Signers, func(a, b int) bool { return cert.Signers[a] < cert.Signers[b] })
+	cert.Delegations = make([]data.Delegation, len(cert.Signers))
+	for _, docHash := range offer.DataHashes {
+		for _, j := range cert.Signers {
+			acc := signers[j]
+			h, err := app.CredentialHash(offer, docHash, acc.Account.Address, s.binding())
+			require.NoError(t, err)
+			sig, err := app.SignHash(acc, h)
+			require.NoError(t, err)
+			cert.Signatures = append(cert.Signatures, sig)
+		}
+	}
+	return &cert
+}
+
+// domain returns the EIP-712 signing domain of the app.
+func (s *setup) domain() app.Domain {
+	return app.Domain{ChainID: big.NewInt(chainID), Contract: ethwallet.AsEthAddr(s.app.Def())}
+}
+
+func newAccount(t *testing.T) *simple.Account {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	addr := ethwallet.AsWalletAddr(crypto.PubkeyToAddress(key.PublicKey))
+	acc, err := simple.NewWallet(key).Unlock(addr)
+	require.NoError(t, err)
+	return acc.(*simple.Account)
+}
+
+func TestCredentialSwapApp_ValidTransition(t *testing.T) {
+	s := newSetup(t)
+	h := app.ComputeDocumentHash([]byte("document"))
+
+	t.Run("offer", func(t *testing.T) {
+		cur := s.newState(&data.DefaultData{}, 5, 5)
+		next := s.newState(pending(s.offer(h, 1, 5)), 5, 5)
+		require.NoError(t, s.validTransition(t, cur, next, holderIdx))
+	})
+
+	t.Run("offer by issuer", func(t *testing.T) {
+		cur := s.newState(&data.DefaultData{}, 5)
+		next := s.newState(pending(s.offer(h, 0, 5)), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, issuerIdx), app.ErrInvalidBuyer)
+	})
+
+	t.Run("offer insufficient funds", func(t *testing.T) {
+		cur := s.newState(&data.DefaultData{}, 5, 1)
+		next := s.newState(pending(s.offer(h, 1, 2)), 5, 1)
+		require.Error(t, s.validTransition(t, cur, next, holderIdx))
+	})
+
+	t.Run("offer invalid asset", func(t *testing.T) {
+		cur := s.newState(&data.DefaultData{}, 5)
+		next := s.newState(pending(s.offer(h, 1, 1)), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrInvalidAsset)
+	})
+
+	t.Run("cert", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 1, 2)), 5, 5)
+		next := s.newState(s.cert(t, 1, app.SchemeHash, h), 5, 5)
+		next.Balances[1][holderIdx] = big.NewInt(3)
+		next.Balances[1][issuerIdx] = big.NewInt(2)
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("cert wrong asset", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 1, 2)), 5, 5)
+		next := s.newState(s.cert(t, 1, app.SchemeHash, h), 5, 5)
+		next.Balances[0][holderIdx] = big.NewInt(3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("cert unknown offer", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(s.cert(t, 2, app.SchemeHash, h), 3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		require.ErrorIs(t, s.validTransition(t, cur, next, issuerIdx), app.ErrExpectedOffer)
+	})
+
+	t.Run("offer expired", func(t *testing.T) {
+		cur := s.newState(&data.DefaultData{}, 5)
+		next := s.newState(pending(s.offer(h, 0, 1)), 5)
+		next.Version = offerExpiry
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrOfferExpired)
+	})
+
+	t.Run("cert expired", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(s.cert(t, 1, app.SchemeHash, h), 3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		next.Version = offerExpiry + 1
+		require.ErrorIs(t, s.validTransition(t, cur, next, issuerIdx), app.ErrOfferExpired)
+	})
+
+	t.Run("keep offer", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next.Version = 1
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+
+		// The buyer cannot advance the version to let the offer expire.
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrOfferPending)
+	})
+
+	t.Run("offer zero price", func(t *testing.T) {
+		cur := s.newState(&data.DefaultData{}, 5)
+		next := s.newState(pending(s.offer(h, 0, 0)), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrInvalidOffer)
+	})
+
+	t.Run("add offers until expiry", func(t *testing.T) {
+		// The buyer adds offers to advance the version up to the expiry of
+		// its first offer, which it then tries to cancel.
+		o1 := s.offer(h, 0, 1)
+		cur := s.newState(pending(o1), 20)
+		offers := []*data.Offer{o1}
+		for v := uint64(1); v < offerExpiry; v++ {
+			o := s.offer(h, 0, 1)
+			o.ID, o.Expiry = v+1, v+offerExpiry
+			offers = append(offers, o)
+			next := s.newState(pending(offers...), 20)
+			next.Version = v
+			require.NoError(t, s.validTransition(t, cur, next, holderIdx))
+			cur = next
+		}
+
+		o := s.offer(h, 0, 1)
+		o.ID, o.Expiry = offerExpiry+1, 2*offerExpiry
+		next := s.newState(pending(append(offers, o)...), 20)
+		next.Version = offerExpiry
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrOfferPending)
+
+		next = s.newState(pending(offers[1:]...), 20)
+		next.Version = offerExpiry
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrOfferNotExpired)
+
+		// The issuer can still answer the first offer.
+		cert := s.cert(t, 1, app.SchemeHash, h)
+		for _, o := range offers[1:] {
+			cert.Pending = append(cert.Pending, *o)
+		}
+		next = s.newState(cert, 19)
+		next.Balances[0][issuerIdx] = big.NewInt(1)
+		next.Version = offerExpiry
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("change offer", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(pending(s.offer(h, 0, 1)), 5)
+		next.Version = 1
+		require.Error(t, s.validTransition(t, cur, next, holderIdx))
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(&data.DefaultData{}, 5)
+		next.Version = offerExpiry + 1
+		require.NoError(t, s.validTransition(t, cur, next, holderIdx))
+	})
+
+	t.Run("cancel before expiry", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(&data.DefaultData{}, 5)
+		next.Version = offerExpiry
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrOfferNotExpired)
+	})
+
+	t.Run("withdraw", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(&data.DefaultData{}, 5)
+		next.Version = 1
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("cancel unequal balances", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(&data.DefaultData{}, 3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		next.Version = 1
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("batch cert", func(t *testing.T) {
+		h2 := app.ComputeDocumentHash([]byte("document 2"))
+		offer := s.offer(h, 0, 2)
+		offer.DataHashes = append(offer.DataHashes, h2)
+		offer.Prices = append(offer.Prices, big.NewInt(1))
+		cur := s.newState(pending(offer), 5)
+		next := s.newState(s.cert(t, 1, app.SchemeHash, h, h2), 2)
+		next.Balances[0][issuerIdx] = big.NewInt(3)
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+
+		next = s.newState(s.cert(t, 1, app.SchemeHash, h), 2)
+		next.Balances[0][issuerIdx] = big.NewInt(3)
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("offer unequal lengths", func(t *testing.T) {
+		offer := s.offer(h, 0, 2)
+		offer.Prices = append(offer.Prices, big.NewInt(1))
+		cur := s.newState(&data.DefaultData{}, 5)
+		next := s.newState(pending(offer), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrInvalidOffer)
+	})
+
+	t.Run("concurrent offers", func(t *testing.T) {
+		h2 := app.ComputeDocumentHash([]byte("document 2"))
+		o1, o2 := s.offer(h, 0, 2), s.offer(h2, 0, 3)
+		o2.ID = 2
+
+		// Add second offer.
+		cur := s.newState(pending(o1), 5)
+		next := s.newState(pending(o1, o2), 5)
+		require.NoError(t, s.validTransition(t, cur, next, holderIdx))
+
+		// Insufficient funds for both offers.
+		o2.Prices[0] = big.NewInt(4)
+		next = s.newState(pending(o1, o2), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrInsufficientBalance)
+		o2.Prices[0] = big.NewInt(3)
+
+		// Duplicate ID.
+		o2.ID = 1
+		next = s.newState(pending(o1, o2), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrInvalidOffer)
+		o2.ID = 2
+
+		// Fulfill second offer first.
+		cur = s.newState(pending(o1, o2), 5)
+		cert := s.cert(t, 2, app.SchemeHash, h2)
+		cert.Pending = []data.Offer{*o1}
+		next = s.newState(cert, 2)
+		next.Balances[0][issuerIdx] = big.NewInt(3)
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+
+		// Fulfilling must not drop other offers.
+		cert.Pending = nil
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("quote", func(t *testing.T) {
+		offer := s.offer(h, 0, 2)
+		oh, err := offer.Hash()
+		require.NoError(t, err)
+		sig, err := app.SignHash(s.issuer, oh)
+		require.NoError(t, err)
+		quote := &data.Quote{Offer: *offer, Signature: sig}
+
+		cur := s.newState(&data.DefaultData{}, 5)
+		next := s.newState(quote, 5)
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+
+		// Accept quote.
+		cur, next = next, s.newState(pending(offer), 5)
+		next.Version = 1
+		require.NoError(t, s.validTransition(t, cur, next, holderIdx))
+
+		// Modified quote.
+		quote.Offer.Prices[0] = big.NewInt(1)
+		cur, next = s.newState(&data.DefaultData{}, 5), s.newState(quote, 5)
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("typed cert", func(t *testing.T) {
+		offer := s.offer(h, 0, 2)
+		offer.Scheme = app.SchemeEIP712
+		cur := s.newState(pending(offer), 5)
+		next := s.newState(s.cert(t, 1, app.SchemeEIP712, h), 3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+
+		// A signature in the hash scheme is invalid.
+		next.Data = s.cert(t, 1, app.SchemeHash, h)
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("offer invalid scheme", func(t *testing.T) {
+		offer := s.offer(h, 0, 2)
+		offer.Scheme = 2
+		cur := s.newState(&data.DefaultData{}, 5)
+		next := s.newState(pending(offer), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrInvalidScheme)
+	})
+
+	t.Run("cert wrong channel", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(s.cert(t, 1, app.SchemeHash, h), 3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		cur.ID[0]++
+		next.ID[0]++
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("cert invalid signature", func(t *testing.T) {
+		cur := s.newState(pending(s.offer(h, 0, 2)), 5)
+		next := s.newState(s.cert(t, 1, app.SchemeHash, app.ComputeDocumentHash([]byte("other"))), 3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("threshold cert", func(t *testing.T) {
+		co1, co2 := newAccount(t), newAccount(t)
+		offer := s.offer(h, 0, 2)
+		offer.Issuers = append(offer.Issuers, co1.Account.Address, co2.Account.Address)
+		offer.Threshold = 2
+		cur := s.newState(pending(offer), 5)
+		next := s.newState(s.signCert(t, offer, map[uint16]*simple.Account{0: s.issuer, 2: co2}), 3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+
+		// The threshold is not met.
+		next.Data = s.signCert(t, offer, map[uint16]*simple.Account{1: co1})
+		require.ErrorIs(t, s.validTransition(t, cur, next, issuerIdx), app.ErrThresholdNotMet)
+
+		// The same issuer cannot sign twice.
+		cert := s.signCert(t, offer, map[uint16]*simple.Account{1: co1})
+		cert.Signers = []uint16{1, 1}
+		cert.Signatures = append(cert.Signatures, cert.Signatures[0])
+		next.Data = cert
+		require.ErrorIs(t, s.validTransition(t, cur, next, issuerIdx), app.ErrInvalidSigner)
+
+		// A signature by a co-issuer is missing.
+		cert = s.signCert(t, offer, map[uint16]*simple.Account{0: s.issuer, 1: co1})
+		cert.Signatures[1] = cert.Signatures[0]
+		next.Data = cert
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+	})
+
+	t.Run("delegated cert", func(t *testing.T) {
+		delegate := newAccount(t)
+		now := time.Now()
+		dom := s.domain()
+		d, err := app.SignDelegation(app.NewAccountSigner(s.issuer), delegate.Account.Address, now, now.Add(time.Hour), dom)
+		require.NoError(t, err)
+
+		offer := s.offer(h, 0, 2)
+		offer.Scheme = app.SchemeEIP712
+		offer.CreatedAt = uint64(now.Unix())
+		issuer := s.issuer.Account.Address
+		ch, err := app.CredentialHash(offer, h, issuer, s.binding())
+		require.NoError(t, err)
+		sig, err := app.SignHash(delegate, ch)
+		require.NoError(t, err)
+		cert := &data.Cert{
+			ID:          offer.ID,
+			Signers:     []uint16{0},
+			Delegations: []data.Delegation{*d},
+			Signatures:  [][data.SigLen]byte{sig},
+		}
+
+		cur := s.newState(pending(offer), 5)
+		next := s.newState(cert, 3)
+		next.Balances[0][issuerIdx] = big.NewInt(2)
+		require.NoError(t, s.validTransition(t, cur, next, issuerIdx))
+
+		// Without the delegation, the signature is invalid.
+		cert.Delegations = []data.Delegation{{}}
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+
+		// The delegation must be signed by the issuer.
+		forged, err := app.SignDelegation(app.NewAccountSigner(delegate), delegate.Account.Address, now, now.Add(time.Hour), dom)
+		require.NoError(t, err)
+		cert.Delegations = []data.Delegation{*forged}
+		require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+
+		// The delegation must be signed for the chain and the app contract.
+		for _, other := range []app.Domain{
+			{ChainID: big.NewInt(chainID + 1), Contract: dom.Contract},
+			{ChainID: dom.ChainID, Contract: common.Address{2}},
+		} {
+			d, err := app.SignDelegation(app.NewAccountSigner(s.issuer), delegate.Account.Address, now, now.Add(time.Hour), other)
+			require.NoError(t, err)
+			cert.Delegations = []data.Delegation{*d}
+			require.Error(t, s.validTransition(t, cur, next, issuerIdx))
+		}
+
+		// The delegation must be valid when the offer is created.
+		for _, createdAt := range []time.Time{now.Add(-time.Second), now.Add(time.Hour + time.Second)} {
+			offer.CreatedAt = uint64(createdAt.Unix())
+			ch, err := app.CredentialHash(offer, h, issuer, s.binding())
+			require.NoError(t, err)
+			sig, err := app.SignHash(delegate, ch)
+			require.NoError(t, err)
+			cert.Delegations = []data.Delegation{*d}
+			cert.Signatures = [][data.SigLen]byte{sig}
+			cur := s.newState(pending(offer), 5)
+			require.ErrorIs(t, s.validTransition(t, cur, next, issuerIdx), app.ErrDelegationNotValid)
+		}
+	})
+
+	t.Run("offer invalid threshold", func(t *testing.T) {
+		offer := s.offer(h, 0, 2)
+		offer.Threshold = 2
+		cur := s.newState(&data.DefaultData{}, 5)
+		next := s.newState(pending(offer), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrInvalidOffer)
+
+		// Issuers must be distinct.
+		offer.Issuers = append(offer.Issuers, offer.Issuers[0])
+		next = s.newState(pending(offer), 5)
+		require.ErrorIs(t, s.validTransition(t, cur, next, holderIdx), app.ErrInvalidOffer)
+	})
+}
+
+func TestCredential_Verify(t *testing.T) {
+	s := newSetup(t)
+	doc := []byte("document")
+	h := app.ComputeDocumentHash(doc)
+	issuer := s.issuer.Account.Address
+
+	for _, scheme := range []app.SigScheme{app.SchemeHash, app.SchemeEIP712} {
+		cert := s.cert(t, 1, scheme, h)
+		cred := &app.Credential{
+			Document:   doc,
+			Signatures: []app.Signature{cert.Signatures[0][:]},
+			Issuers:    []common.Address{issuer},
+			Scheme:     scheme,
+			ChainID:    big.NewInt(chainID),
+			Binding:    s.binding(),
+		}
+		require.NoError(t, cred.Verify(issuer, cred.Holder))
+		require.ErrorIs(t, cred.Verify(issuer, common.Address{}), app.ErrInvalidHolder)
+
+		// The signature is not valid for another holder or channel.
+		other := *cred
+		other.Holder = common.Address{2}
+		require.Error(t, other.Verify(issuer, other.Holder))
+		other = *cred
+		other.ChannelID[0]++
+		require.Error(t, other.Verify(issuer, other.Holder))
+	}
+}
+
+func TestCredential_VerifySalted(t *testing.T) {
+	s := newSetup(t)
+	doc := []byte("document")
+	salt, err := app.NewSalt()
+	require.NoError(t, err)
+	require.Equal(t, app.ComputeDocumentHash(doc), app.ComputeSaltedDocumentHash(doc, nil))
+
+	h := app.ComputeSaltedDocumentHash(doc, salt)
+	require.NotEqual(t, app.ComputeDocumentHash(doc), h)
+	cert := s.cert(t, 1, app.SchemeHash, h)
+	issuer := s.issuer.Account.Address
+	cred := &app.Credential{
+		Document:   doc,
+		Salt:       salt,
+		Signatures: []app.Signature{cert.Signatures[0][:]},
+		Issuers:    []common.Address{issuer},
+		Scheme:     app.SchemeHash,
+		Binding:    s.binding(),
+	}
+	require.NoError(t, cred.Verify(issuer, cred.Holder))
+
+	cred.Salt = nil
+	require.Error(t, cred.Verify(issuer, cred.Holder))
+}
+
+func TestCredential_VerifyThreshold(t *testing.T) {
+	s := newSetup(t)
+	doc := []byte("document")
+	h := app.ComputeDocumentHash(doc)
+	co := newAccount(t)
+	issuers := []common.Address{s.issuer.Account.Address, co.Account.Address}
+
+	offer := s.offer(h, 0, 1)
+	offer.Issuers = issuers
+	cert := s.signCert(t, offer, map[uint16]*simple.Account{0: s.issuer, 1: co})
+	cred := &app.Credential{
+		Document:   doc,
+		Signatures: []app.Signature{cert.Signatures[0][:], cert.Signatures[1][:]},
+		Issuers:    issuers,
+		ChainID:    big.NewInt(chainID),
+		Binding:    s.binding(),
+	}
+	require.NoError(t, cred.VerifyThreshold(issuers, 2, cred.Holder))
+	require.NoError(t, cred.Verify(co.Account.Address, cred.Holder))
+
+	// Signatures by untrusted issuers do not count.
+	require.ErrorIs(t, cred.VerifyThreshold(issuers[1:], 2, cred.Holder), app.ErrThresholdNotMet)
+	require.ErrorIs(t, cred.VerifyThreshold([]common.Address{{3}}, 1, cred.Holder), app.ErrThresholdNotMet)
+}
+
+func TestCredential_VerifyDelegated(t *testing.T) {
+	s := newSetup(t)
+	doc := []byte("document")
+	h := app.ComputeDocumentHash(doc)
+	issuer := s.issuer.Account.Address
+	delegate := newAccount(t)
+	now := time.Now()
+	d, err := app.SignDelegation(app.NewAccountSigner(s.issuer), delegate.Account.Address, now, now.Add(time.Hour), s.domain())
+	require.NoError(t, err)
+
+	offer := s.offer(h, 0, 1)
+	offer.Scheme = app.SchemeEIP712
+	ch, err := app.CredentialHash(offer, h, issuer, s.binding())
+	require.NoError(t, err)
+	sig, err := app.SignHash(delegate, ch)
+	require.NoError(t, err)
+	cred := &app.Credential{
+		Document:    doc,
+		Signatures:  []app.Signature{sig[:]},
+		Issuers:     []common.Address{issuer},
+		Delegations: []data.Delegation{*d},
+		Scheme:      app.SchemeEIP712,
+		ChainID:     big.NewInt(chainID),
+		CreatedAt:   uint64(now.Unix()),
+		Binding:     s.binding(),
+	}
+	require.NoError(t, cred.Verify(issuer, cred.Holder))
+
+	// The delegation is not valid outside of its validity window.
+	cred.CreatedAt = uint64(now.Add(2 * time.Hour).Unix())
+	require.ErrorIs(t, cred.Verify(issuer, cred.Holder), app.ErrDelegationNotValid)
+}
@@ -0,0 +1,33 @@
+package app
+
+//go:generate go run ./internal/bindgen
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	ethchannel "perun.network/go-perun/backend/ethereum/channel"
+)
+
+// ValidateCredentialSwap checks that the contract at the given address is the
+// CredentialSwap app. The expected runtime code is obtained by simulating the
+// deployment of CredentialSwapBin on the backend. If the code differs, the
+// returned error satisfies ethchannel.IsErrInvalidContractCode.
+func ValidateCredentialSwap(ctx context.Context, backend bind.ContractCaller, addr common.Address) error {
+	code, err := backend.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return errors.WithMessage(err, "fetching contract code")
+	}
+	runtime, err := backend.CallContract(ctx, ethereum.CallMsg{Data: common.FromHex(CredentialSwapBin)}, nil)
+	if err != nil {
+		return errors.WithMessage(err, "simulating app deployment")
+	}
+	if len(code) == 0 || !bytes.Equal(code, runtime) {
+		return errors.Wrapf(ethchannel.ErrInvalidContractCode, "app at %v", addr)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package app_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/stretchr/testify/require"
+	ethchannel "perun.network/go-perun/backend/ethereum/channel"
+	"perun.network/go-perun/channel"
+)
+
+// deployCredentialSwap deploys the app contract on a simulated backend.
+func deployCredentialSwap(t *testing.T) (*backends.SimulatedBackend, common.Address) {
+	t.Helper()
+	require := require.New(t)
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+	}, 10_000_000)
+	t.Cleanup(func() { backend.Close() })
+
+	tr, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(chainID))
+	require.NoError(err)
+	addr, _, _, err := app.DeployCredentialSwap(tr, backend)
+	require.NoError(err)
+	backend.Commit()
+	return backend, addr
+}
+
+// validTransition checks the transition with the app and the contract and
+// requires that both agree. It returns the error of the app.
+func (s *setup) validTransition(t *testing.T, cur, next *channel.State, actorIdx channel.Index) error {
+	t.Helper()
+	err := s.app.ValidTransition(s.params, cur, next, actorIdx)
+	contractErr := s.contract.ValidTransition(nil,
+		toEthParams(s.params), toEthState(cur), toEthState(next), big.NewInt(int64(actorIdx)))
+	if err == nil {
+		require.NoError(t, contractErr, "contract rejects valid transition")
+	} else {
+		require.Error(t, contractErr, "contract accepts invalid transition: %v", err)
+	}
+	return err
+}
+
+func toEthParams(p *channel.Params) app.ChannelParams {
+	ep := ethchannel.ToEthParams(p)
+	return app.ChannelParams{
+		ChallengeDuration: ep.ChallengeDuration,
+		Nonce:             ep.Nonce,
+		Participants:      ep.Participants,
+		App:               ep.App,
+		LedgerChannel:     ep.LedgerChannel,
+		VirtualChannel:    ep.VirtualChannel,
+	}
+}
+
+// toEthState converts a state without sub-channels to its contract binding.
+func toEthState(s *channel.State) app.ChannelState {
+	es := ethchannel.ToEthState(s)
+	return app.ChannelState{
+		ChannelID: es.ChannelID,
+		Version:   es.Version,
+		Outcome: app.ChannelAllocation{
+			Assets:   es.Outcome.Assets,
+			Balances: es.Outcome.Balances,
+		},
+		AppData: es.AppData,
+		IsFinal: es.IsFinal,
+	}
+}
+
+func TestValidateCredentialSwap(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	backend, addr := deployCredentialSwap(t)
+
+	require.NoError(app.ValidateCredentialSwap(ctx, backend, addr))
+
+	err := app.ValidateCredentialSwap(ctx, backend, common.HexToAddress("0x01"))
+	require.True(ethchannel.IsErrInvalidContractCode(err))
+}
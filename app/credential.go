@@ -0,0 +1,166 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"perun.network/go-perun/channel"
+)
+
+// Binding binds a credential signature to the channel in which the credential
+// was issued, to the holder and to the app contract. This prevents a signature
+// from being replayed for another channel or holder.
+type Binding struct {
+	ChannelID channel.ID
+	Holder    common.Address
+	Contract  common.Address
+}
+
+// CredentialMessage holds the fields of a credential that an issuer signs for
+// a single document. Signers compute the signed hash from these fields.
+type CredentialMessage struct {
+	DocHash Hash
+	Issuer  common.Address
+	Scheme  SigScheme
+	ChainID *big.Int
+	Binding
+}
+
+// NewCredentialMessage returns the message that the given issuer signs for the
+// document with the given hash, which is part of the given offer.
+func NewCredentialMessage(offer *data.Offer, docHash Hash, issuer common.Address, b Binding) *CredentialMessage {
+	return &CredentialMessage{
+		DocHash: docHash,
+		Issuer:  issuer,
+		Scheme:  offer.Scheme,
+		ChainID: offer.ChainID,
+		Binding: b,
+	}
+}
+
+// Hash returns the hash that is signed for the message.
+func (m *CredentialMessage) Hash() (Hash, error) {
+	return credentialHash(m.Scheme, m.DocHash, m.Issuer, m.ChainID, m.Binding)
+}
+
+// CredentialHash returns the hash that the given issuer signs for the document
+// with the given hash, which is part of the given offer.
+func CredentialHash(offer *data.Offer, docHash Hash, issuer common.Address, b Binding) (Hash, error) {
+	return NewCredentialMessage(offer, docHash, issuer, b).Hash()
+}
+
+func credentialHash(scheme SigScheme, docHash Hash, issuer common.Address, chainID *big.Int, b Binding) (Hash, error) {
+	switch scheme {
+	case SchemeHash:
+		return crypto.Keccak256Hash(
+			docHash[:],
+			b.ChannelID[:],
+			common.LeftPadBytes(b.Holder[:], 32),
+			common.LeftPadBytes(b.Contract[:], 32),
+		), nil
+	case SchemeEIP712:
+		if chainID == nil {
+			return Hash{}, fmt.Errorf("missing chain ID")
+		}
+		c := TypedCredential{
+			DocHash:   docHash,
+			ChannelID: b.ChannelID,
+			Holder:    b.Holder,
+			Issuer:    issuer,
+			ChainID:   chainID,
+			Contract:  b.Contract,
+		}
+		return c.Hash(), nil
+	default:
+		return Hash{}, ErrInvalidScheme
+	}
+}
+
+// Credential is a document together with the issuers' signatures on it. The
+// i-th signature is by the i-th issuer, or by its delegated key if the i-th
+// delegation is not zero. Delegations may be nil if all issuers signed
+// directly. The issuers sign the commitment to the document with the given
+// salt. The signatures are bound to the channel, the holder and the contract
+// given by the binding. CreatedAt is the creation time of the offer for which
+// the credential was issued, in Unix seconds. Delegations must be valid at
+// that time.
+type Credential struct {
+	Document    []byte
+	Salt        []byte
+	Signatures  []Signature
+	Issuers     []common.Address
+	Delegations []data.Delegation
+	Scheme      SigScheme
+	ChainID     *big.Int
+	CreatedAt   uint64
+	Binding
+}
+
+// Verify checks that the credential was issued by the given issuer to the
+// given holder.
+func (c *Credential) Verify(issuer, holder common.Address) error {
+	return c.VerifyThreshold([]common.Address{issuer}, 1, holder)
+}
+
+// VerifyThreshold checks that the credential was issued to the given holder
+// and signed by at least `threshold` distinct issuers from `issuers`. The
+// delegations of the signers must be valid at CreatedAt.
+func (c *Credential) VerifyThreshold(issuers []common.Address, threshold int, holder common.Address) error {
+	if c.Holder != holder {
+		return ErrInvalidHolder
+	} else if len(c.Signatures) != len(c.Issuers) {
+		return fmt.Errorf("unequal number of signatures and issuers")
+	} else if c.Delegations != nil && len(c.Delegations) != len(c.Issuers) {
+		return fmt.Errorf("unequal number of delegations and issuers")
+	}
+
+	docHash := ComputeSaltedDocumentHash(c.Document, c.Salt)
+	signed := make(map[common.Address]bool)
+	for i, issuer := range c.Issuers {
+		if !containsAddress(issuers, issuer) || signed[issuer] {
+			continue
+		} else if len(c.Signatures[i]) != data.SigLen {
+			return fmt.Errorf("invalid signature length")
+		}
+
+		key := issuer
+		if c.Delegations != nil {
+			var err error
+			dom := Domain{c.ChainID, c.Contract}
+			if key, err = SigningKey(issuer, &c.Delegations[i], dom, c.CreatedAt); err != nil {
+				return fmt.Errorf("issuer %v: %w", issuer, err)
+			}
+		}
+		h, err := credentialHash(c.Scheme, docHash, issuer, c.ChainID, c.Binding)
+		if err != nil {
+			return fmt.Errorf("computing credential hash: %w", err)
+		}
+		var sig [data.SigLen]byte
+		copy(sig[:], c.Signatures[i])
+		if err := VerifySig(sig, h, key); err != nil {
+			return fmt.Errorf("verifying signature of %v: %w", issuer, err)
+		}
+		signed[issuer] = true
+	}
+
+	if len(signed) < threshold {
+		return ErrThresholdNotMet
+	}
+	return nil
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Credential) String() string {
+	return fmt.Sprintf("Document: \"%s\" Signatures: \"%x\"", c.Document, c.Signatures)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	appabi "github.com/perun-network/perun-credential-payment/app/abi"
 	"perun.network/go-perun/channel"
 )
@@ -20,8 +21,9 @@ type Mode = uint8
 
 const (
 	defaultMode Mode = iota
-	offerMode
+	pendingMode
 	certMode
+	quoteMode
 )
 
 // DefaultData represents the default state.
@@ -42,32 +44,125 @@ func (d *DefaultData) Clone() channel.Data {
 	return &_d
 }
 
-// Offer represents an offer.
+// Offer represents an offer for one or more documents. The document hashes
+// and their prices are given as lists of equal length. The credentials must
+// be signed by at least Threshold distinct issuers from Issuers. The payment
+// can be claimed until the channel reaches version Expiry. Afterwards, the
+// buyer can cancel the offer. CreatedAt is the creation time of the offer in
+// Unix seconds, at which delegated signing keys must be valid. The ID
+// identifies the offer among the pending offers of a channel. Scheme
+// determines how the issuers sign the credentials and ChainID is the chain on
+// which typed credential and delegation signatures are valid.
 type Offer struct {
-	Issuer   common.Address
-	DataHash [HashLen]byte
-	Price    *big.Int
-	Buyer    uint16
+	ID         uint64
+	Issuers    []common.Address
+	Threshold  uint16
+	DataHashes [][HashLen]byte
+	Prices     []*big.Int
+	Asset      uint16
+	Buyer      uint16
+	Expiry     uint64
+	CreatedAt  uint64
+	Scheme     uint8
+	ChainID    *big.Int
 }
 
 func (a Offer) Equal(b *Offer) bool {
-	return a.Issuer == b.Issuer &&
-		a.DataHash == b.DataHash &&
-		a.Price.Cmp(b.Price) == 0 &&
-		a.Buyer == b.Buyer
+	if len(a.Issuers) != len(b.Issuers) ||
+		len(a.DataHashes) != len(b.DataHashes) ||
+		len(a.Prices) != len(b.Prices) {
+		return false
+	}
+	for i := range a.Issuers {
+		if a.Issuers[i] != b.Issuers[i] {
+			return false
+		}
+	}
+	for i := range a.DataHashes {
+		if a.DataHashes[i] != b.DataHashes[i] {
+			return false
+		}
+	}
+	for i := range a.Prices {
+		if a.Prices[i].Cmp(b.Prices[i]) != 0 {
+			return false
+		}
+	}
+	return a.ID == b.ID &&
+		a.Threshold == b.Threshold &&
+		a.Asset == b.Asset &&
+		a.Buyer == b.Buyer &&
+		a.Expiry == b.Expiry &&
+		a.CreatedAt == b.CreatedAt &&
+		a.Scheme == b.Scheme &&
+		equalBigInt(a.ChainID, b.ChainID)
+}
+
+func equalBigInt(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
+
+// TotalPrice returns the sum of the prices of the offered documents.
+func (a Offer) TotalPrice() *big.Int {
+	sum := new(big.Int)
+	for _, p := range a.Prices {
+		sum.Add(sum, p)
+	}
+	return sum
+}
+
+// Hash returns the hash of the offer, which is the Keccak-256 hash of its ABI
+// encoding.
+func (a *Offer) Hash() ([HashLen]byte, error) {
+	enc, err := offerArgs.Pack(a)
+	if err != nil {
+		return [HashLen]byte{}, err
+	}
+	return crypto.Keccak256Hash(enc), nil
+}
+
+// Clone returns a deep copy of the offer.
+func (a *Offer) Clone() *Offer {
+	_a := *a
+	_a.Issuers = append([]common.Address(nil), a.Issuers...)
+	_a.DataHashes = append([][HashLen]byte(nil), a.DataHashes...)
+	_a.Prices = make([]*big.Int, len(a.Prices))
+	for i, p := range a.Prices {
+		_a.Prices[i] = new(big.Int).Set(p)
+	}
+	if a.ChainID != nil {
+		_a.ChainID = new(big.Int).Set(a.ChainID)
+	}
+	return &_a
+}
+
+var offerComponents = []abi.ArgumentMarshaling{
+	{Type: "uint64", Name: "ID"},
+	{Type: "address[]", Name: "issuers"},
+	{Type: "uint16", Name: "threshold"},
+	{Type: "bytes32[]", Name: "dataHashes"},
+	{Type: "uint256[]", Name: "prices"},
+	{Type: "uint16", Name: "asset"},
+	{Type: "uint16", Name: "buyer"},
+	{Type: "uint64", Name: "expiry"},
+	{Type: "uint64", Name: "createdAt"},
+	{Type: "uint8", Name: "scheme"},
+	{Type: "uint256", Name: "chainID"},
 }
 
 var offerType = func() abi.Type {
-	t, err := abi.NewType(
-		"tuple",
-		"offer",
-		[]abi.ArgumentMarshaling{
-			{Type: "address", Name: "issuer"},
-			{Type: "bytes32", Name: "dataHash"},
-			{Type: "uint256", Name: "price"},
-			{Type: "uint16", Name: "buyer"},
-		},
-	)
+	t, err := abi.NewType("tuple", "offer", offerComponents)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}()
+
+var offersType = func() abi.Type {
+	t, err := abi.NewType("tuple[]", "offer[]", offerComponents)
 	if err != nil {
 		panic(err)
 	}
@@ -78,41 +173,187 @@ var offerArgs = appabi.Arguments{
 	{Name: "offer", Type: offerType},
 }
 
+// Pending represents a state with pending offers.
+type Pending struct {
+	Offers []Offer
+}
+
+var pendingArgs = appabi.Arguments{
+	{Name: "offers", Type: offersType},
+}
+
 // Encode encodes app data onto an io.Writer.
-func (d *Offer) Encode(w io.Writer) error {
-	body, err := offerArgs.Pack(d)
+func (d *Pending) Encode(w io.Writer) error {
+	body, err := pendingArgs.Pack(nonNilOffers(d.Offers))
+	if err != nil {
+		return err
+	}
+
+	f := &dataFrame{
+		Mode: pendingMode,
+		Data: body,
+	}
+	return f.Encode(w)
+}
+
+func (d *Pending) Unmarshal(b []byte) error {
+	values, err := pendingArgs.Unpack(b)
+	if err != nil {
+		return fmt.Errorf("unpacking: %w", err)
+	}
+	abi.ConvertType(values[0], &d.Offers)
+	return nil
+}
+
+// Clone returns a deep copy of the app data.
+func (d *Pending) Clone() channel.Data {
+	return &Pending{Offers: cloneOffers(d.Offers)}
+}
+
+// Quote represents an offer that is proposed by an issuer. The buyer accepts
+// the quote by adding the offer to the pending offers. The signature is the
+// signature of the first issuer of the offer on the hash of the offer.
+type Quote struct {
+	Offer     Offer
+	Signature [SigLen]byte
+	Pending   []Offer
+}
+
+var quoteArgs = appabi.Arguments{
+	{Name: "offer", Type: offerType},
+	{Name: "signature", Type: appabi.Bytes},
+	{Name: "pending", Type: offersType},
+}
+
+// Encode encodes the data onto an io.Writer.
+func (d *Quote) Encode(w io.Writer) error {
+	body, err := quoteArgs.Pack(&d.Offer, d.Signature[:], nonNilOffers(d.Pending))
 	if err != nil {
 		return err
 	}
 
 	f := &dataFrame{
-		Mode: offerMode,
+		Mode: quoteMode,
 		Data: body,
 	}
 	return f.Encode(w)
 }
 
-func (d *Offer) Unmarshal(b []byte) error {
-	return appabi.Unpack(b, d, offerArgs)
+func (d *Quote) Unmarshal(b []byte) error {
+	values, err := quoteArgs.Unpack(b)
+	if err != nil {
+		return fmt.Errorf("unpacking: %w", err)
+	}
+
+	sig := values[1].([]byte)
+	if len(sig) != SigLen {
+		return fmt.Errorf("invalid signature length")
+	}
+	abi.ConvertType(values[0], &d.Offer)
+	copy(d.Signature[:], sig)
+	abi.ConvertType(values[2], &d.Pending)
+	return nil
 }
 
 // Clone returns a deep copy of the app data.
-func (d *Offer) Clone() channel.Data {
+func (d *Quote) Clone() channel.Data {
 	_d := *d
-	_d.Price = new(big.Int).Set(d.Price)
+	_d.Offer = *d.Offer.Clone()
+	_d.Pending = cloneOffers(d.Pending)
 	return &_d
 }
 
-// Cert represents an offer response.
-type Cert struct {
+// Delegation authorizes a signing key to sign credentials on behalf of an
+// issuer for offers created within the given validity window, given in Unix
+// seconds. The signature is by the issuer. A delegation with the zero Delegate
+// means that the issuer signs directly.
+type Delegation struct {
+	Delegate  common.Address
+	NotBefore uint64
+	NotAfter  uint64
 	Signature [SigLen]byte
 }
 
+// IsZero returns whether the delegation is the zero delegation.
+func (d *Delegation) IsZero() bool {
+	return d.Delegate == common.Address{}
+}
+
+var delegationsType = func() abi.Type {
+	t, err := abi.NewType("tuple[]", "delegation[]", []abi.ArgumentMarshaling{
+		{Type: "address", Name: "delegate"},
+		{Type: "uint64", Name: "notBefore"},
+		{Type: "uint64", Name: "notAfter"},
+		{Type: "bytes", Name: "signature"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return t
+}()
+
+// delegationTuple is the ABI representation of a Delegation.
+type delegationTuple struct {
+	Delegate  common.Address
+	NotBefore uint64
+	NotAfter  uint64
+	Signature []byte
+}
+
+// Cert represents the response to the pending offer with the given ID. The
+// signers are the indices of the signing issuers in the offer in ascending
+// order. The j-th delegation authorizes the key of the j-th signer. The
+// signatures are ordered by document and then by signer, that is, the
+// signature of the j-th signer on the i-th document is at index
+// i*len(Signers)+j. Cert also holds the remaining pending offers.
+type Cert struct {
+	ID          uint64
+	Signers     []uint16
+	Delegations []Delegation
+	Signatures  [][SigLen]byte
+	Pending     []Offer
+}
+
+// Signature returns the signature of the j-th signer on the i-th document.
+func (d *Cert) Signature(i, j int) [SigLen]byte {
+	return d.Signatures[i*len(d.Signers)+j]
+}
+
+var certArgs = appabi.Arguments{
+	{Name: "id", Type: appabi.Uint64},
+	{Name: "signers", Type: appabi.Uint16Array},
+	{Name: "delegations", Type: delegationsType},
+	{Name: "signatures", Type: appabi.BytesArray},
+	{Name: "pending", Type: offersType},
+}
+
 // Encode encodes the data onto an io.Writer.
 func (d *Cert) Encode(w io.Writer) error {
+	sigs := make([][]byte, len(d.Signatures))
+	for i := range d.Signatures {
+		sigs[i] = d.Signatures[i][:]
+	}
+	signers := d.Signers
+	if signers == nil {
+		signers = []uint16{}
+	}
+	delegations := make([]delegationTuple, len(d.Delegations))
+	for i, del := range d.Delegations {
+		delegations[i] = delegationTuple{
+			Delegate:  del.Delegate,
+			NotBefore: del.NotBefore,
+			NotAfter:  del.NotAfter,
+			Signature: append([]byte(nil), del.Signature[:]...),
+		}
+	}
+	body, err := certArgs.Pack(d.ID, signers, delegations, sigs, nonNilOffers(d.Pending))
+	if err != nil {
+		return err
+	}
+
 	f := &dataFrame{
 		Mode: certMode,
-		Data: d.Signature[:],
+		Data: body,
 	}
 	return f.Encode(w)
 }
@@ -120,17 +361,113 @@ func (d *Cert) Encode(w io.Writer) error {
 // Clone returns a deep copy of the app data.
 func (d *Cert) Clone() channel.Data {
 	_d := *d
+	_d.Signers = append([]uint16(nil), d.Signers...)
+	_d.Delegations = append([]Delegation(nil), d.Delegations...)
+	_d.Signatures = append([][SigLen]byte(nil), d.Signatures...)
+	_d.Pending = cloneOffers(d.Pending)
 	return &_d
 }
 
 func (d *Cert) Unmarshal(b []byte) error {
-	if len(b) != len(d.Signature) {
-		return fmt.Errorf("invalid signature length")
+	values, err := certArgs.Unpack(b)
+	if err != nil {
+		return fmt.Errorf("unpacking: %w", err)
+	}
+
+	d.ID = values[0].(uint64)
+	d.Signers = values[1].([]uint16)
+	var delegations []delegationTuple
+	abi.ConvertType(values[2], &delegations)
+	d.Delegations = nil
+	if len(delegations) > 0 {
+		d.Delegations = make([]Delegation, len(delegations))
 	}
-	copy(d.Signature[:], b)
+	for i, del := range delegations {
+		if len(del.Signature) != SigLen {
+			return fmt.Errorf("invalid delegation signature length")
+		}
+		d.Delegations[i] = Delegation{
+			Delegate:  del.Delegate,
+			NotBefore: del.NotBefore,
+			NotAfter:  del.NotAfter,
+		}
+		copy(d.Delegations[i].Signature[:], del.Signature)
+	}
+	sigs := values[3].([][]byte)
+	d.Signatures = make([][SigLen]byte, len(sigs))
+	for i, sig := range sigs {
+		if len(sig) != SigLen {
+			return fmt.Errorf("invalid signature length")
+		}
+		copy(d.Signatures[i][:], sig)
+	}
+	abi.ConvertType(values[4], &d.Pending)
 	return nil
 }
 
+// PendingOffers returns the pending offers of the given channel data.
+func PendingOffers(d channel.Data) []Offer {
+	switch d := d.(type) {
+	case *Pending:
+		return d.Offers
+	case *Cert:
+		return d.Pending
+	case *Quote:
+		return d.Pending
+	default:
+		return nil
+	}
+}
+
+// WithPendingOffers returns channel data with the given pending offers. A
+// quote is retained, while a certificate is dropped.
+func WithPendingOffers(d channel.Data, offers []Offer) channel.Data {
+	if q, ok := d.(*Quote); ok {
+		_q := q.Clone().(*Quote)
+		_q.Pending = cloneOffers(offers)
+		return _q
+	} else if len(offers) == 0 {
+		return &DefaultData{}
+	}
+	return &Pending{Offers: cloneOffers(offers)}
+}
+
+// FindOffer returns the index of the offer with the given ID.
+func FindOffer(offers []Offer, id uint64) (int, bool) {
+	for i := range offers {
+		if offers[i].ID == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// RemoveOffer returns a copy of the offers without the offer at index i.
+func RemoveOffer(offers []Offer, i int) []Offer {
+	rest := append([]Offer(nil), offers[:i]...)
+	return append(rest, offers[i+1:]...)
+}
+
+func cloneOffers(offers []Offer) []Offer {
+	if offers == nil {
+		return nil
+	}
+	_offers := make([]Offer, len(offers))
+	for i := range offers {
+		_offers[i] = *offers[i].Clone()
+	}
+	return _offers
+}
+
+// nonNilOffers returns an empty slice if offers is nil, as the ABI encoder
+// does not accept nil slices.
+func nonNilOffers(offers []Offer) []Offer {
+	if offers == nil {
+		return []Offer{}
+	}
+	return offers
+}
+
 func Decode(r io.Reader) (channel.Data, error) {
 	var f dataFrame
 	err := f.Decode(r)
@@ -141,12 +478,15 @@ func Decode(r io.Reader) (channel.Data, error) {
 	switch f.Mode {
 	case defaultMode:
 		return &DefaultData{}, nil
-	case offerMode:
-		var offer Offer
-		return &offer, offer.Unmarshal(f.Data)
+	case pendingMode:
+		var pending Pending
+		return &pending, pending.Unmarshal(f.Data)
 	case certMode:
 		var cert Cert
 		return &cert, cert.Unmarshal(f.Data)
+	case quoteMode:
+		var quote Quote
+		return &quote, quote.Unmarshal(f.Data)
 	default:
 		return nil, fmt.Errorf("unknown mode")
 	}
@@ -0,0 +1,50 @@
+package data_test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"github.com/stretchr/testify/require"
+	"perun.network/go-perun/channel"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	offer := func(id uint64) data.Offer {
+		return data.Offer{
+			ID:         id,
+			Issuers:    []common.Address{{1}, {10}},
+			Threshold:  2,
+			DataHashes: [][data.HashLen]byte{{2}, {3}},
+			Prices:     []*big.Int{big.NewInt(4), big.NewInt(5)},
+			Asset:      1,
+			Buyer:      0,
+			Expiry:     6,
+			CreatedAt:  1700000000,
+			Scheme:     1,
+			ChainID:    big.NewInt(1337),
+		}
+	}
+
+	for _, d := range []channel.Data{
+		&data.DefaultData{},
+		&data.Pending{Offers: []data.Offer{offer(1), offer(2)}},
+		&data.Cert{ID: 1, Signers: []uint16{0, 1}, Signatures: [][data.SigLen]byte{{7}, {8}, {9}, {10}}, Pending: []data.Offer{offer(2)}},
+		&data.Cert{
+			ID:          1,
+			Signers:     []uint16{0},
+			Delegations: []data.Delegation{{Delegate: common.Address{5}, NotBefore: 10, NotAfter: 20, Signature: [data.SigLen]byte{6}}},
+			Signatures:  [][data.SigLen]byte{{7}},
+			Pending:     []data.Offer{},
+		},
+		&data.Quote{Offer: offer(3), Signature: [data.SigLen]byte{9}, Pending: []data.Offer{offer(1)}},
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, d.Encode(&buf))
+		decoded, err := data.Decode(&buf)
+		require.NoError(t, err)
+		require.Equal(t, d, decoded)
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/perun-network/perun-credential-payment/app/data"
+)
+
+var delegationTypeHash = crypto.Keccak256Hash([]byte(
+	"Delegation(address issuer,address delegate,uint64 notBefore,uint64 notAfter)"))
+
+// DelegationHash returns the EIP-712 hash that the issuer signs to authorize
+// the delegate of the given delegation in the given domain.
+func DelegationHash(issuer common.Address, d *data.Delegation, dom Domain) Hash {
+	structHash := crypto.Keccak256(
+		delegationTypeHash[:],
+		common.LeftPadBytes(issuer[:], 32),
+		common.LeftPadBytes(d.Delegate[:], 32),
+		math.U256Bytes(new(big.Int).SetUint64(d.NotBefore)),
+		math.U256Bytes(new(big.Int).SetUint64(d.NotAfter)),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, dom.separator(), structHash)
+}
+
+// SignDelegation authorizes the given delegate to sign credentials on behalf
+// of the issuer in the given domain for offers created from `notBefore` until
+// `notAfter`.
+func SignDelegation(issuer *AccountSigner, delegate common.Address, notBefore, notAfter time.Time, dom Domain) (*data.Delegation, error) {
+	if delegate == (common.Address{}) {
+		return nil, fmt.Errorf("zero delegate")
+	} else if notBefore.Unix() < 0 || notAfter.Before(notBefore) {
+		return nil, fmt.Errorf("invalid validity window")
+	} else if dom.ChainID == nil {
+		return nil, fmt.Errorf("missing chain ID")
+	}
+	d := &data.Delegation{
+		Delegate:  delegate,
+		NotBefore: uint64(notBefore.Unix()),
+		NotAfter:  uint64(notAfter.Unix()),
+	}
+	sig, err := issuer.SignHash(DelegationHash(issuer.Address(), d, dom))
+	if err != nil {
+		return nil, fmt.Errorf("signing delegation: %w", err)
+	}
+	d.Signature = sig
+	return d, nil
+}
+
+// VerifyDelegation checks that the delegation was signed by the given issuer
+// in the given domain.
+func VerifyDelegation(issuer common.Address, d *data.Delegation, dom Domain) error {
+	if d.NotAfter < d.NotBefore {
+		return fmt.Errorf("invalid validity window")
+	} else if dom.ChainID == nil {
+		return fmt.Errorf("missing chain ID")
+	}
+	return VerifySig(d.Signature, DelegationHash(issuer, d, dom), issuer)
+}
+
+// SigningKey returns the address that signs on behalf of the given issuer for
+// an offer that was created at the given time in Unix seconds. It is the
+// delegate if the delegation is not zero and the issuer otherwise.
+func SigningKey(issuer common.Address, d *data.Delegation, dom Domain, createdAt uint64) (common.Address, error) {
+	if d.IsZero() {
+		return issuer, nil
+	} else if err := VerifyDelegation(issuer, d, dom); err != nil {
+		return common.Address{}, fmt.Errorf("verifying delegation: %w", err)
+	} else if createdAt < d.NotBefore || createdAt > d.NotAfter {
+		return common.Address{}, ErrDelegationNotValid
+	}
+	return d.Delegate, nil
+}
+
+// DelegatedSigner is a Signer whose key is authorized by a delegation to sign
+// credentials on behalf of an issuer.
+type DelegatedSigner struct {
+	Signer
+	issuer     common.Address
+	delegation data.Delegation
+}
+
+// NewDelegatedSigner returns a signer for the given issuer that signs with the
+// delegated key of `signer`. The delegation must be valid in the given domain.
+func NewDelegatedSigner(signer Signer, issuer common.Address, d *data.Delegation, dom Domain) (*DelegatedSigner, error) {
+	if d.Delegate != signer.Address() {
+		return nil, fmt.Errorf("delegate %v does not match signer %v", d.Delegate, signer.Address())
+	} else if err := VerifyDelegation(issuer, d, dom); err != nil {
+		return nil, fmt.Errorf("verifying delegation: %w", err)
+	}
+	return &DelegatedSigner{Signer: signer, issuer: issuer, delegation: *d}, nil
+}
+
+// Issuer returns the address of the issuer on whose behalf the signer signs.
+func (s *DelegatedSigner) Issuer() common.Address {
+	return s.issuer
+}
+
+// Delegation returns the delegation of the signer.
+func (s *DelegatedSigner) Delegation() *data.Delegation {
+	d := s.delegation
+	return &d
+}
+
+// IssuerOf returns the issuer on whose behalf the given signer signs and the
+// delegation of the signer, which is zero if the signer is the issuer itself.
+func IssuerOf(signer Signer) (common.Address, data.Delegation) {
+	if s, ok := signer.(*DelegatedSigner); ok {
+		return s.issuer, s.delegation
+	}
+	return signer.Address(), data.Delegation{}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"perun.network/go-perun/channel"
+)
+
+// SigScheme determines the message that the issuer signs for a credential.
+type SigScheme = uint8
+
+const (
+	// SchemeHash is the scheme in which the issuer signs the document hash.
+	SchemeHash SigScheme = iota
+	// SchemeEIP712 is the scheme in which the issuer signs a typed credential
+	// according to EIP-712.
+	SchemeEIP712
+)
+
+const (
+	eip712DomainName    = "CredentialSwap"
+	eip712DomainVersion = "1"
+)
+
+var (
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte(
+		"EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	credentialTypeHash = crypto.Keccak256Hash([]byte(
+		"Credential(bytes32 docHash,bytes32 channelId,address holder,address issuer,uint256 chainId,address contract)"))
+)
+
+// Domain is the EIP-712 signing domain of the app contract on a chain.
+// Credentials in the EIP-712 scheme and delegations are only valid in their
+// domain.
+type Domain struct {
+	ChainID  *big.Int
+	Contract common.Address
+}
+
+// separator returns the EIP-712 domain separator.
+func (d Domain) separator() []byte {
+	return crypto.Keccak256(
+		eip712DomainTypeHash[:],
+		crypto.Keccak256([]byte(eip712DomainName)),
+		crypto.Keccak256([]byte(eip712DomainVersion)),
+		math.U256Bytes(new(big.Int).Set(d.ChainID)),
+		common.LeftPadBytes(d.Contract[:], 32),
+	)
+}
+
+// TypedCredential is the EIP-712 typed data that the issuer signs for a
+// document. The contract is the address of the app contract and also serves as
+// the verifying contract of the signing domain.
+type TypedCredential struct {
+	DocHash   Hash
+	ChannelID channel.ID
+	Holder    common.Address
+	Issuer    common.Address
+	ChainID   *big.Int
+	Contract  common.Address
+}
+
+// Hash returns the EIP-712 hash of the typed credential.
+func (c *TypedCredential) Hash() Hash {
+	domainSeparator := Domain{c.ChainID, c.Contract}.separator()
+	structHash := crypto.Keccak256(
+		credentialTypeHash[:],
+		c.DocHash[:],
+		c.ChannelID[:],
+		common.LeftPadBytes(c.Holder[:], 32),
+		common.LeftPadBytes(c.Issuer[:], 32),
+		math.U256Bytes(new(big.Int).Set(c.ChainID)),
+		common.LeftPadBytes(c.Contract[:], 32),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
@@ -0,0 +1,223 @@
+// Command bindgen compiles CredentialSwap.sol and generates its Go binding
+// CredentialSwap.go. It is invoked by go generate in the app package.
+//
+// The contract is compiled with solc --standard-json using fixed settings so
+// that the binding can be reproduced. The perun-eth-contracts submodule
+// declares ^0.7.0 but compiles unchanged with solc 0.8. Its pragmas are
+// therefore rewritten when the sources are loaded.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// solcVersion is the compiler version that the binding is built with.
+	solcVersion = "0.8.21"
+	// evmVersion is the target EVM version. Later versions use PUSH0, which
+	// the simulated backend of go-ethereum does not support.
+	evmVersion = "istanbul"
+	// submodule is the import prefix of the perun-eth-contracts submodule.
+	submodule = "perun-eth-contracts/"
+)
+
+var (
+	importRe    = regexp.MustCompile(`import\s+"([^"]+)";`)
+	oldPragmaRe = regexp.MustCompile(`pragma solidity \^0\.7\.0;`)
+)
+
+func main() {
+	solc := flag.String("solc", "solc", "solc binary")
+	contracts := flag.String("contracts", submodule, "path of the perun-eth-contracts checkout")
+	sol := flag.String("sol", "CredentialSwap.sol", "Solidity source file")
+	pkg := flag.String("pkg", "app", "package name of the binding")
+	out := flag.String("out", "CredentialSwap.go", "output file")
+	flag.Parse()
+
+	if err := checkVersion(*solc); err != nil {
+		log.Fatal(err)
+	}
+	sources := make(map[string]source)
+	if err := load(sources, *sol, *contracts); err != nil {
+		log.Fatal(err)
+	}
+	compiled, err := compile(*solc, sources)
+	if err != nil {
+		log.Fatal(err)
+	}
+	code, err := generate(compiled, *pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, []byte(code+"\n"), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type source struct {
+	Content string `json:"content"`
+}
+
+// checkVersion checks that the solc binary has the expected version.
+func checkVersion(solc string) error {
+	out, err := exec.Command(solc, "--version").Output()
+	if err != nil {
+		return fmt.Errorf("running %s: %w", solc, err)
+	}
+	if !strings.Contains(string(out), "Version: "+solcVersion+"+") {
+		return fmt.Errorf("solc %s required, got %q", solcVersion, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// load reads the source file p and its imports into sources.
+func load(sources map[string]source, p, contracts string) error {
+	if _, ok := sources[p]; ok {
+		return nil
+	}
+	file := p
+	if strings.HasPrefix(p, submodule) {
+		file = filepath.Join(contracts, strings.TrimPrefix(p, submodule))
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(p, submodule) {
+		content = oldPragmaRe.ReplaceAll(content, []byte("pragma solidity >=0.7.0 <0.9.0;"))
+	}
+	sources[p] = source{Content: string(content)}
+	for _, m := range importRe.FindAllSubmatch(content, -1) {
+		if err := load(sources, path.Join(path.Dir(p), string(m[1])), contracts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type (
+	compilerInput struct {
+		Language string            `json:"language"`
+		Sources  map[string]source `json:"sources"`
+		Settings settings          `json:"settings"`
+	}
+
+	settings struct {
+		Optimizer       optimizer                      `json:"optimizer"`
+		EVMVersion      string                         `json:"evmVersion"`
+		OutputSelection map[string]map[string][]string `json:"outputSelection"`
+	}
+
+	optimizer struct {
+		Enabled bool `json:"enabled"`
+		Runs    int  `json:"runs"`
+	}
+
+	compilerOutput struct {
+		Errors []struct {
+			Severity         string `json:"severity"`
+			FormattedMessage string `json:"formattedMessage"`
+		} `json:"errors"`
+		Contracts map[string]map[string]contract `json:"contracts"`
+	}
+
+	contract struct {
+		ABI json.RawMessage `json:"abi"`
+		EVM struct {
+			Bytecode struct {
+				Object string `json:"object"`
+			} `json:"bytecode"`
+			MethodIdentifiers map[string]string `json:"methodIdentifiers"`
+		} `json:"evm"`
+	}
+)
+
+// compile compiles the sources with solc and returns the contracts by their
+// fully qualified names.
+func compile(solc string, sources map[string]source) (map[string]contract, error) {
+	in, err := json.Marshal(compilerInput{
+		Language: "Solidity",
+		Sources:  sources,
+		Settings: settings{
+			Optimizer:  optimizer{Enabled: true, Runs: 200},
+			EVMVersion: evmVersion,
+			OutputSelection: map[string]map[string][]string{
+				"*": {"*": {"abi", "evm.bytecode.object", "evm.methodIdentifiers"}},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(solc, "--standard-json")
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stderr = os.Stderr
+	outJSON, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %w", solc, err)
+	}
+	var out compilerOutput
+	if err := json.Unmarshal(outJSON, &out); err != nil {
+		return nil, fmt.Errorf("decoding compiler output: %w", err)
+	}
+	failed := false
+	for _, e := range out.Errors {
+		fmt.Fprint(os.Stderr, e.FormattedMessage)
+		failed = failed || e.Severity == "error"
+	}
+	if failed {
+		return nil, fmt.Errorf("compilation failed")
+	}
+	contracts := make(map[string]contract)
+	for file, cs := range out.Contracts {
+		for name, c := range cs {
+			contracts[file+":"+name] = c
+		}
+	}
+	return contracts, nil
+}
+
+// generate returns the Go binding of the contracts, ordered by contract name
+// like abigen.
+func generate(contracts map[string]contract, pkg string) (string, error) {
+	names := make([]string, 0, len(contracts))
+	for n := range contracts {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return typeName(names[i]) < typeName(names[j])
+	})
+
+	var types, abis, bins []string
+	var sigs []map[string]string
+	libs := make(map[string]string)
+	for _, name := range names {
+		c := contracts[name]
+		types = append(types, typeName(name))
+		abis = append(abis, string(c.ABI))
+		bins = append(bins, "0x"+c.EVM.Bytecode.Object)
+		sigs = append(sigs, c.EVM.MethodIdentifiers)
+		libs[crypto.Keccak256Hash([]byte(name)).String()[2:36]] = typeName(name)
+	}
+	return bind.Bind(types, abis, bins, sigs, pkg, bind.LangGo, libs, map[string]string{})
+}
+
+// typeName returns the contract name of a fully qualified name.
+func typeName(name string) string {
+	return name[strings.LastIndex(name, ":")+1:]
+}
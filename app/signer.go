@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"perun.network/go-perun/backend/ethereum/wallet/simple"
+)
+
+// Signer signs credentials and quotes on behalf of an issuer. It computes the
+// signed hashes itself, so that it only signs credentials and offers. The
+// returned signatures are in the format expected by VerifySig.
+type Signer interface {
+	// Address returns the address of the signing key.
+	Address() common.Address
+	// SignCredential signs the given credential message.
+	SignCredential(m *CredentialMessage) ([data.SigLen]byte, error)
+	// SignOffer signs the given offer, which quotes it to the buyer.
+	SignOffer(o *data.Offer) ([data.SigLen]byte, error)
+}
+
+// AccountSigner is a Signer backed by an in-process account.
+type AccountSigner struct {
+	acc *simple.Account
+}
+
+// NewAccountSigner returns a Signer that signs with the given account.
+func NewAccountSigner(acc *simple.Account) *AccountSigner {
+	return &AccountSigner{acc: acc}
+}
+
+func (s *AccountSigner) Address() common.Address {
+	return s.acc.Account.Address
+}
+
+func (s *AccountSigner) SignCredential(m *CredentialMessage) ([data.SigLen]byte, error) {
+	h, err := m.Hash()
+	if err != nil {
+		return [data.SigLen]byte{}, fmt.Errorf("computing credential hash: %w", err)
+	}
+	return s.SignHash(h)
+}
+
+func (s *AccountSigner) SignOffer(o *data.Offer) ([data.SigLen]byte, error) {
+	h, err := o.Hash()
+	if err != nil {
+		return [data.SigLen]byte{}, fmt.Errorf("hashing offer: %w", err)
+	}
+	return s.SignHash(h)
+}
+
+// SignHash signs the given hash. Unlike the methods of Signer, it signs any
+// hash, for example delegations.
+func (s *AccountSigner) SignHash(h [data.HashLen]byte) ([data.SigLen]byte, error) {
+	return SignHash(s.acc, h)
+}
@@ -3,7 +3,9 @@ package client
 import (
 	"context"
 	"fmt"
+	"log"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -34,14 +36,25 @@ type PaymentAcceptancePolicy = func(
 	hasOverdrawn bool,
 ) (ok bool)
 
+// Funding describes the amount of an asset that is deposited into a
+// connection. The asset is identified by the address of its asset holder.
+type Funding struct {
+	Asset   common.Address
+	Balance channel.Bal
+}
+
 type Client struct {
 	perunClient       *perun.Client
 	assetHolderAddr   common.Address
 	assetHolder       *assetholdereth.AssetHolderETH
+	assetHoldersERC20 []perun.AssetHolderERC20
 	challengeDuration time.Duration
 	appAddress        common.Address
+	chainID           *big.Int
 	channelProposals  chan *connection.ChannelProposal
 	connections       *connection.Registry
+	restored          []*connection.Connection
+	events            *connection.Feed
 }
 
 func StartClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
@@ -49,9 +62,27 @@ func StartClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
 	if err != nil {
 		return nil, errors.WithMessage(err, "creating perun client")
 	}
+	// Release the listener and the database if starting fails.
+	started := false
+	defer func() {
+		if started {
+			return
+		}
+		if err := perunClient.Close(); err != nil {
+			log.Printf("Closing perun client: %v", err)
+		}
+	}()
 
 	if err := ethchannel.ValidateAssetHolderETH(ctx, perunClient.ContractBackend, cfg.AssetHolder, cfg.Adjudicator); err != nil {
-		return nil, fmt.Errorf("validating adjudicator: %w", err)
+		return nil, fmt.Errorf("validating asset holder: %w", err)
+	}
+	for _, ah := range cfg.AssetHoldersERC20 {
+		if err := ethchannel.ValidateAssetHolderERC20(ctx, perunClient.ContractBackend, ah.AssetHolder, cfg.Adjudicator, ah.Token); err != nil {
+			return nil, fmt.Errorf("validating ERC20 asset holder %v: %w", ah.AssetHolder, err)
+		}
+	}
+	if err := pkgapp.ValidateCredentialSwap(ctx, perunClient.ContractBackend, cfg.AppAddress); err != nil {
+		return nil, fmt.Errorf("validating app: %w", err)
 	}
 	ah, err := assetholdereth.NewAssetHolderETH(cfg.AssetHolder, perunClient.ContractBackend)
 	if err != nil {
@@ -62,10 +93,21 @@ func StartClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
 		perunClient:       perunClient,
 		assetHolderAddr:   cfg.AssetHolder,
 		assetHolder:       ah,
+		assetHoldersERC20: cfg.AssetHoldersERC20,
 		challengeDuration: cfg.ChallengeDuration,
 		appAddress:        cfg.AppAddress,
+		chainID:           cfg.ChainID,
 		channelProposals:  make(chan *connection.ChannelProposal),
 		connections:       connection.NewRegistry(),
+		events:            connection.NewFeed(),
+	}
+
+	// The app must be known to decode proposals and restored channel data.
+	channel.RegisterApp(pkgapp.NewCredentialSwapApp(ethwallet.AsWalletAddr(cfg.AppAddress)))
+	if perunClient.PersistRestorer != nil {
+		if err := c.restore(ctx); err != nil {
+			return nil, fmt.Errorf("restoring channels: %w", err)
+		}
 	}
 
 	h := &handler{Client: c}
@@ -73,19 +115,35 @@ func StartClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
 	go c.perunClient.PerunClient.Handle(h, h)
 	go c.perunClient.Bus.Listen(c.perunClient.Listener)
 
+	started = true
 	return c, nil
 }
 
-func (c *Client) Connect(ctx context.Context, peer wire.Address, balance channel.Bal) (*connection.Connection, error) {
+// Connect proposes a connection to the given peer. The connection is funded
+// by us with the given amounts of the given assets. The order of the funding
+// determines the asset indices of the connection.
+func (c *Client) Connect(ctx context.Context, peer wire.Address, funding ...Funding) (*connection.Connection, error) {
+	if len(funding) == 0 {
+		return nil, fmt.Errorf("no funding")
+	}
+
 	app := pkgapp.NewCredentialSwapApp(ethwallet.AsWalletAddr(c.appAddress))
 	peers := []wire.Address{c.perunClient.Account.Address(), peer}
 	withApp := client.WithApp(app, app.InitData())
 
-	asset := ethwallet.AsWalletAddr(c.assetHolderAddr)
-	alloc := channel.NewAllocation(2, asset)
+	assets := make([]channel.Asset, len(funding))
+	for i, f := range funding {
+		if !c.isAssetHolder(f.Asset) {
+			return nil, fmt.Errorf("unknown asset holder: %v", f.Asset)
+		}
+		assets[i] = ethwallet.AsWalletAddr(f.Asset)
+	}
+	alloc := channel.NewAllocation(2, assets...)
 	ourIndex, peerIndex := channel.Index(0), channel.Index(1)
-	alloc.SetBalance(ourIndex, asset, balance)
-	alloc.SetBalance(peerIndex, asset, big.NewInt(0))
+	for i, f := range funding {
+		alloc.Balances[i][ourIndex] = new(big.Int).Set(f.Balance)
+		alloc.Balances[i][peerIndex] = big.NewInt(0)
+	}
 
 	prop, err := client.NewLedgerChannelProposal(
 		c.challengeDurationInSeconds(),
@@ -102,9 +160,20 @@ func (c *Client) Connect(ctx context.Context, peer wire.Address, balance channel
 	if err != nil {
 		return nil, fmt.Errorf("proposing channel: %w", err)
 	}
-	conn := connection.NewConnection(ch)
+	conn := connection.NewConnection(ch, c.chainID)
+	conn.SetEventFeed(c.events)
 	c.connections.Add(conn)
+	c.watch(conn)
+	c.events.Emit(&connection.ConnectionOpened{
+		ChannelEvent: connection.ChannelEvent{ChannelID: conn.ID()},
+		Peer:         peer,
+	})
 
+	return conn, nil
+}
+
+// watch starts watching the given connection for disputes.
+func (c *Client) watch(conn *connection.Connection) {
 	h := connection.NewEventHandler(conn)
 	go func() {
 		err := conn.Watch(h)
@@ -112,8 +181,52 @@ func (c *Client) Connect(ctx context.Context, peer wire.Address, balance channel
 			c.Logf("Watching failed: %v", err)
 		}
 	}()
+}
 
-	return conn, nil
+// restore restores the persisted channels and rebuilds their connections.
+func (c *Client) restore(ctx context.Context) error {
+	var (
+		mu       sync.Mutex
+		channels []*client.Channel
+	)
+	pc := c.perunClient.PerunClient
+	pc.OnNewChannel(func(ch *client.Channel) {
+		mu.Lock()
+		channels = append(channels, ch)
+		mu.Unlock()
+	})
+	err := pc.Restore(ctx)
+	pc.OnNewChannel(func(*client.Channel) {})
+	if err != nil {
+		return err
+	}
+
+	for _, ch := range channels {
+		conn, err := connection.RestoreConnection(ch, c.chainID)
+		if err != nil {
+			return fmt.Errorf("restoring connection %x: %w", ch.ID(), err)
+		}
+		conn.SetEventFeed(c.events)
+		c.connections.Add(conn)
+		c.watch(conn)
+		if conn.DisputeManager().Resume() {
+			c.Logf("Resuming settlement of channel %x", ch.ID())
+		}
+		c.restored = append(c.restored, conn)
+	}
+	return nil
+}
+
+// RestoredConnections returns the connections that were restored from
+// persistence when the client was started.
+func (c *Client) RestoredConnections() []*connection.Connection {
+	return append([]*connection.Connection(nil), c.restored...)
+}
+
+// Connections returns the open connections of the client, including the
+// restored ones.
+func (c *Client) Connections() []*connection.Connection {
+	return c.connections.All()
 }
 
 func (c *Client) NextConnectionRequest(ctx context.Context) (*connection.ConnectionRequest, error) {
@@ -121,14 +234,34 @@ func (c *Client) NextConnectionRequest(ctx context.Context) (*connection.Connect
 	if !ok {
 		return nil, fmt.Errorf("channel closed")
 	}
-	return connection.NewConnectionRequest(p, c.PerunAddress(), c.connections), nil
+	return connection.NewConnectionRequest(p, c.PerunAddress(), c.connections, c.chainID, c.events), nil
+}
+
+// Subscribe returns a subscription to the events of the client and its
+// connections. The subscription must be closed when it is no longer used.
+func (c *Client) Subscribe() *connection.Subscription {
+	return c.events.Subscribe()
 }
 
 func (c *Client) Shutdown() {
+	for _, conn := range c.connections.All() {
+		conn.StopWatching()
+	}
 	c.perunClient.PerunClient.Close()
 	c.perunClient.Bus.Close()
+	if pr := c.perunClient.PersistRestorer; pr != nil {
+		if err := pr.Close(); err != nil {
+			c.Logf("Closing persistence: %v", err)
+		}
+	}
 }
 
 func (c *Client) Account() *simple.Account {
 	return c.perunClient.Account
 }
+
+// Signer returns a signer for credentials that signs with the account of the
+// client.
+func (c *Client) Signer() pkgapp.Signer {
+	return pkgapp.NewAccountSigner(c.perunClient.Account)
+}
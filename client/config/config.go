@@ -0,0 +1,258 @@
+// Package config loads the client configuration from a file.
+//
+// The file is in YAML format, which includes JSON. Relative paths in the file
+// are resolved relative to the directory of the file. An example:
+//
+//	node: ws://127.0.0.1:8545
+//	chainID: 1337
+//	host: 127.0.0.1:8546
+//	database: credpay-db
+//	contracts:
+//	  adjudicator: "0x..."
+//	  assetHolder: "0x..."
+//	  app: "0x..."
+//	  erc20:
+//	    - assetHolder: "0x..."
+//	      token: "0x..."
+//	peers:
+//	  - address: "0x..."
+//	    host: 127.0.0.1:8547
+//	challengeDuration: 10m
+//	txFinality: 1
+//	key:
+//	  file: issuer.key
+//
+// Instead of listing the contract addresses, the file may name a manifest
+// written by package pkg/deploy:
+//
+//	manifest: contracts.json
+//
+// Addresses under contracts take precedence over those of the manifest.
+//
+// See KeySource for the other sources of the private key.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/client"
+	"github.com/perun-network/perun-credential-payment/client/perun"
+	"github.com/perun-network/perun-credential-payment/pkg/deploy"
+	"gopkg.in/yaml.v3"
+	"perun.network/go-perun/backend/ethereum/wallet"
+)
+
+// Default values of optional fields.
+const (
+	DefaultDialerTimeout     = 10 * time.Second
+	DefaultChallengeDuration = 10 * time.Minute
+	DefaultTxFinality        = 1
+)
+
+// File is the content of a configuration file.
+type File struct {
+	Node              string        `yaml:"node"`
+	ChainID           uint64        `yaml:"chainID"`
+	Host              string        `yaml:"host"`
+	Database          string        `yaml:"database"`
+	Contracts         Contracts     `yaml:"contracts"`
+	Manifest          string        `yaml:"manifest"`
+	Peers             []Peer        `yaml:"peers"`
+	ChallengeDuration time.Duration `yaml:"challengeDuration"`
+	DialerTimeout     time.Duration `yaml:"dialerTimeout"`
+	TxFinality        uint64        `yaml:"txFinality"`
+	Key               KeySource     `yaml:"key"`
+}
+
+// Contracts are the addresses of the contracts.
+type Contracts struct {
+	Adjudicator string  `yaml:"adjudicator"`
+	AssetHolder string  `yaml:"assetHolder"`
+	App         string  `yaml:"app"`
+	ERC20       []ERC20 `yaml:"erc20"`
+}
+
+// ERC20 is an ERC20 asset holder and the token it holds.
+type ERC20 struct {
+	AssetHolder string `yaml:"assetHolder"`
+	Token       string `yaml:"token"`
+}
+
+// Peer is a peer and its network address.
+type Peer struct {
+	Address string `yaml:"address"`
+	Host    string `yaml:"host"`
+}
+
+// Load reads the configuration file at the given path and returns the client
+// configuration.
+func Load(path string) (client.ClientConfig, error) {
+	f, err := Read(path)
+	if err != nil {
+		return client.ClientConfig{}, err
+	}
+	cfg, err := f.ClientConfig()
+	if err != nil {
+		return client.ClientConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Read reads the configuration file at the given path. Relative paths in the
+// file are resolved relative to its directory.
+func Read(path string) (*File, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	f, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f.resolvePaths(filepath.Dir(path))
+	return f, nil
+}
+
+// Parse parses the content of a configuration file. Unknown fields are an
+// error.
+func Parse(b []byte) (*File, error) {
+	f := &File{
+		ChallengeDuration: DefaultChallengeDuration,
+		DialerTimeout:     DefaultDialerTimeout,
+		TxFinality:        DefaultTxFinality,
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(f); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	return f, nil
+}
+
+func (f *File) resolvePaths(dir string) {
+	resolve := func(p *string) {
+		if *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	resolve(&f.Database)
+	resolve(&f.Manifest)
+	f.Key.resolvePaths(resolve)
+}
+
+// ClientConfig validates the configuration and returns the client
+// configuration. The private key is read from the key source.
+func (f *File) ClientConfig() (client.ClientConfig, error) {
+	var errs []string
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, field+": "+fmt.Sprintf(format, args...))
+	}
+	address := func(field, s string) common.Address {
+		if s == "" {
+			fail(field, "missing")
+		} else if !common.IsHexAddress(s) {
+			fail(field, "invalid address %q", s)
+		}
+		return common.HexToAddress(s)
+	}
+
+	if f.Node == "" {
+		fail("node", "missing")
+	}
+	if f.ChainID == 0 {
+		fail("chainID", "missing")
+	}
+	if f.Host == "" {
+		fail("host", "missing")
+	}
+	contracts := f.Contracts
+	if f.Manifest != "" {
+		m, err := deploy.ReadManifest(f.Manifest)
+		if err != nil {
+			fail("manifest", "%v", err)
+		} else if m.ChainID != f.ChainID {
+			fail("manifest", "deployed on chain %d, not %d", m.ChainID, f.ChainID)
+		} else {
+			contracts = contracts.withDefaults(m)
+		}
+	}
+	adj := address("contracts.adjudicator", contracts.Adjudicator)
+	ah := address("contracts.assetHolder", contracts.AssetHolder)
+	app := address("contracts.app", contracts.App)
+	erc20 := make([]perun.AssetHolderERC20, len(contracts.ERC20))
+	for i, e := range contracts.ERC20 {
+		erc20[i] = perun.AssetHolderERC20{
+			AssetHolder: address(fmt.Sprintf("contracts.erc20[%d].assetHolder", i), e.AssetHolder),
+			Token:       address(fmt.Sprintf("contracts.erc20[%d].token", i), e.Token),
+		}
+	}
+	peers := make([]perun.Peer, len(f.Peers))
+	for i, p := range f.Peers {
+		addr := address(fmt.Sprintf("peers[%d].address", i), p.Address)
+		if p.Host == "" {
+			fail(fmt.Sprintf("peers[%d].host", i), "missing")
+		}
+		peers[i] = perun.Peer{Peer: wallet.AsWalletAddr(addr), Address: p.Host}
+	}
+	if f.ChallengeDuration <= 0 {
+		fail("challengeDuration", "must be positive")
+	}
+	if f.DialerTimeout <= 0 {
+		fail("dialerTimeout", "must be positive")
+	}
+	if f.TxFinality == 0 {
+		fail("txFinality", "must be positive")
+	}
+	key, err := f.Key.PrivateKey()
+	if err != nil {
+		fail("key", "%v", err)
+	}
+
+	if len(errs) > 0 {
+		return client.ClientConfig{}, fmt.Errorf("invalid config:\n\t%s", strings.Join(errs, "\n\t"))
+	}
+	return client.ClientConfig{
+		ClientConfig: perun.ClientConfig{
+			PrivateKey:        key,
+			Host:              f.Host,
+			ETHNodeURL:        f.Node,
+			Adjudicator:       adj,
+			AssetHolder:       ah,
+			AssetHoldersERC20: erc20,
+			DialerTimeout:     f.DialerTimeout,
+			Peers:             peers,
+			TxFinality:        f.TxFinality,
+			ChainID:           new(big.Int).SetUint64(f.ChainID),
+			DatabasePath:      f.Database,
+		},
+		ChallengeDuration: f.ChallengeDuration,
+		AppAddress:        app,
+	}, nil
+}
+
+// withDefaults returns the contracts with the empty addresses taken from the
+// manifest. The ERC20 asset holders of the manifest are used if none are
+// given.
+func (c Contracts) withDefaults(m *deploy.Manifest) Contracts {
+	def := func(s *string, addr common.Address) {
+		if *s == "" {
+			*s = addr.Hex()
+		}
+	}
+	def(&c.Adjudicator, m.Adjudicator)
+	def(&c.AssetHolder, m.AssetHolder)
+	def(&c.App, m.App)
+	if len(c.ERC20) == 0 {
+		for _, e := range m.ERC20 {
+			c.ERC20 = append(c.ERC20, ERC20{AssetHolder: e.AssetHolder.Hex(), Token: e.Token.Hex()})
+		}
+	}
+	return c
+}
@@ -0,0 +1,121 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/perun-network/perun-credential-payment/client/config"
+	"github.com/perun-network/perun-credential-payment/pkg/deploy"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testKey  = "1af2e950272dd403de7a5760d41c6e44d92b6d02797e51810795ff03cc2cda4f"
+	testAddr = "0x05e71027e7d2bd6dc2b8cf3b1e8d7a7ac6b5c2b4"
+)
+
+const yamlConfig = `
+node: ws://127.0.0.1:8545
+chainID: 1337
+host: 127.0.0.1:8546
+database: db
+contracts:
+  adjudicator: "` + testAddr + `"
+  assetHolder: "` + testAddr + `"
+  app: "` + testAddr + `"
+peers:
+  - address: "` + testAddr + `"
+    host: 127.0.0.1:8547
+challengeDuration: 30s
+key:
+  file: holder.key
+`
+
+func TestLoad(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credpay.yaml")
+	require.NoError(os.WriteFile(path, []byte(yamlConfig), 0o600))
+	require.NoError(os.WriteFile(filepath.Join(dir, "holder.key"), []byte(testKey+"\n"), 0o600))
+
+	cfg, err := config.Load(path)
+	require.NoError(err)
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(err)
+	require.Equal(key.D, cfg.PrivateKey.D)
+	require.Equal(common.HexToAddress(testAddr), cfg.AppAddress)
+	require.Equal(filepath.Join(dir, "db"), cfg.DatabasePath)
+	require.Equal(30*time.Second, cfg.ChallengeDuration)
+	require.Equal(config.DefaultDialerTimeout, cfg.DialerTimeout)
+	require.EqualValues(config.DefaultTxFinality, cfg.TxFinality)
+	require.EqualValues(1337, cfg.ChainID.Int64())
+	require.Len(cfg.Peers, 1)
+	require.Equal("127.0.0.1:8547", cfg.Peers[0].Address)
+}
+
+func TestLoad_Manifest(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+	m := &deploy.Manifest{
+		ChainID:     1337,
+		Adjudicator: common.HexToAddress("0x01"),
+		AssetHolder: common.HexToAddress("0x02"),
+		App:         common.HexToAddress("0x03"),
+		ERC20:       []deploy.ERC20{{AssetHolder: common.HexToAddress("0x04"), Token: common.HexToAddress("0x05")}},
+	}
+	require.NoError(m.Write(filepath.Join(dir, "contracts.json")))
+	require.NoError(os.WriteFile(filepath.Join(dir, "holder.key"), []byte(testKey), 0o600))
+	cfgFile := `
+node: ws://127.0.0.1:8545
+chainID: 1337
+host: 127.0.0.1:8546
+manifest: contracts.json
+contracts:
+  app: "` + testAddr + `"
+key:
+  file: holder.key
+`
+	path := filepath.Join(dir, "credpay.yaml")
+	require.NoError(os.WriteFile(path, []byte(cfgFile), 0o600))
+
+	cfg, err := config.Load(path)
+	require.NoError(err)
+	require.Equal(m.Adjudicator, cfg.Adjudicator)
+	require.Equal(m.AssetHolder, cfg.AssetHolder)
+	require.Equal(common.HexToAddress(testAddr), cfg.AppAddress)
+	require.Len(cfg.AssetHoldersERC20, 1)
+	require.Equal(m.ERC20[0].Token, cfg.AssetHoldersERC20[0].Token)
+
+	m.ChainID = 1
+	require.NoError(m.Write(filepath.Join(dir, "contracts.json")))
+	_, err = config.Load(path)
+	require.Error(err)
+	require.Contains(err.Error(), "manifest:")
+}
+
+func TestParse_JSON(t *testing.T) {
+	f, err := config.Parse([]byte(`{"node": "ws://127.0.0.1:8545", "chainID": 5, "txFinality": 3}`))
+	require.NoError(t, err)
+	require.Equal(t, "ws://127.0.0.1:8545", f.Node)
+	require.EqualValues(t, 5, f.ChainID)
+	require.EqualValues(t, 3, f.TxFinality)
+}
+
+func TestParse_UnknownField(t *testing.T) {
+	_, err := config.Parse([]byte("nodeURL: ws://127.0.0.1:8545\n"))
+	require.Error(t, err)
+}
+
+func TestClientConfig_Invalid(t *testing.T) {
+	f, err := config.Parse([]byte("node: ws://127.0.0.1:8545\ncontracts:\n  app: 0x1234\n"))
+	require.NoError(t, err)
+	_, err = f.ClientConfig()
+	require.Error(t, err)
+	for _, field := range []string{"chainID", "host", "contracts.adjudicator", "contracts.app", "key"} {
+		require.Contains(t, err.Error(), field+":")
+	}
+}
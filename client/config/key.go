@@ -0,0 +1,223 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// KeySource describes where the private key of the participant is read
+// from. Exactly one of its fields must be set.
+//
+//	key:
+//	  keystore:
+//	    file: UTC--2021-...--0x...
+//	    password: {env: CREDPAY_PASSWORD}
+//
+//	key:
+//	  mnemonic:
+//	    phrase: {file: mnemonic.txt}
+//	    path: m/44'/60'/0'/0/0
+type KeySource struct {
+	// File is a file containing the hex-encoded private key.
+	File string `yaml:"file"`
+	// Env is an environment variable containing the hex-encoded private key.
+	Env string `yaml:"env"`
+	// Keystore is an encrypted go-ethereum keystore file.
+	Keystore *KeystoreSource `yaml:"keystore"`
+	// Mnemonic is a BIP-39 mnemonic.
+	Mnemonic *MnemonicSource `yaml:"mnemonic"`
+}
+
+// KeystoreSource is an encrypted go-ethereum keystore file and the password
+// with which it is decrypted.
+type KeystoreSource struct {
+	File     string `yaml:"file"`
+	Password Secret `yaml:"password"`
+}
+
+// MnemonicSource is a BIP-39 mnemonic from which the key is derived at the
+// given BIP-32 path. The password is the optional BIP-39 passphrase.
+type MnemonicSource struct {
+	Phrase   Secret `yaml:"phrase"`
+	Password Secret `yaml:"password"`
+	// Path is the derivation path. It defaults to DefaultDerivationPath.
+	Path string `yaml:"path"`
+}
+
+// DefaultDerivationPath is the derivation path of the first Ethereum account.
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+// Secret is a secret read from a file or from an environment variable. At
+// most one of its fields must be set. Surrounding whitespace is removed.
+type Secret struct {
+	File string `yaml:"file"`
+	Env  string `yaml:"env"`
+}
+
+func (s *KeySource) resolvePaths(resolve func(*string)) {
+	resolve(&s.File)
+	if s.Keystore != nil {
+		resolve(&s.Keystore.File)
+		resolve(&s.Keystore.Password.File)
+	}
+	if s.Mnemonic != nil {
+		resolve(&s.Mnemonic.Phrase.File)
+		resolve(&s.Mnemonic.Password.File)
+	}
+}
+
+// PrivateKey reads the private key from the source.
+func (s KeySource) PrivateKey() (*ecdsa.PrivateKey, error) {
+	n := 0
+	for _, set := range []bool{s.File != "", s.Env != "", s.Keystore != nil, s.Mnemonic != nil} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return nil, errors.New("exactly one of file, env, keystore and mnemonic must be set")
+	}
+
+	switch {
+	case s.Keystore != nil:
+		return s.Keystore.PrivateKey()
+	case s.Mnemonic != nil:
+		return s.Mnemonic.PrivateKey()
+	}
+	hex, err := Secret{File: s.File, Env: s.Env}.Read()
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+	return key, nil
+}
+
+// PrivateKey decrypts the keystore file.
+func (s *KeystoreSource) PrivateKey() (*ecdsa.PrivateKey, error) {
+	b, err := os.ReadFile(s.File)
+	if err != nil {
+		return nil, fmt.Errorf("reading keystore: %w", err)
+	}
+	password, err := s.Password.Read()
+	if err != nil {
+		return nil, fmt.Errorf("keystore password: %w", err)
+	}
+	key, err := keystore.DecryptKey(b, password)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting keystore: %w", err)
+	}
+	return key.PrivateKey, nil
+}
+
+// PrivateKey derives the key from the mnemonic.
+func (s *MnemonicSource) PrivateKey() (*ecdsa.PrivateKey, error) {
+	phrase, err := s.Phrase.Read()
+	if err != nil {
+		return nil, fmt.Errorf("mnemonic: %w", err)
+	} else if phrase == "" {
+		return nil, errors.New("mnemonic: missing phrase")
+	}
+	password, err := s.Password.Read()
+	if err != nil {
+		return nil, fmt.Errorf("mnemonic password: %w", err)
+	}
+	path := s.Path
+	if path == "" {
+		path = DefaultDerivationPath
+	}
+	return DeriveKey(phrase, password, path)
+}
+
+// Read returns the secret. It is empty if neither field is set.
+func (s Secret) Read() (string, error) {
+	switch {
+	case s.File != "" && s.Env != "":
+		return "", errors.New("both file and env set")
+	case s.File != "":
+		b, err := os.ReadFile(s.File)
+		if err != nil {
+			return "", fmt.Errorf("reading secret: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	case s.Env != "":
+		v, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s not set", s.Env)
+		}
+		return strings.TrimSpace(v), nil
+	default:
+		return "", nil
+	}
+}
+
+// DeriveKey derives the private key at the given BIP-32 path from the given
+// BIP-39 mnemonic and passphrase.
+func DeriveKey(mnemonic, passphrase, path string) (*ecdsa.PrivateKey, error) {
+	dp, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing derivation path: %w", err)
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(strings.Join(strings.Fields(mnemonic), " "), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+
+	key, chainCode := hmacSHA512([]byte("Bitcoin seed"), seed)
+	for _, i := range dp {
+		if key, chainCode, err = deriveChild(key, chainCode, i); err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(key)
+}
+
+// deriveChild derives the BIP-32 child private key with the given index.
+func deriveChild(key, chainCode []byte, i uint32) ([]byte, []byte, error) {
+	var data []byte
+	if i >= 0x80000000 {
+		data = append([]byte{0}, key...)
+	} else {
+		priv, err := crypto.ToECDSA(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&priv.PublicKey)
+	}
+	var index [4]byte
+	binary.BigEndian.PutUint32(index[:], i)
+	data = append(data, index[:]...)
+
+	il, ir := hmacSHA512(chainCode, data)
+	n := crypto.S256().Params().N
+	k := new(big.Int).SetBytes(il)
+	if k.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", i)
+	}
+	k.Add(k, new(big.Int).SetBytes(key)).Mod(k, n)
+	if k.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", i)
+	}
+	return k.FillBytes(make([]byte, 32)), ir, nil
+}
+
+func hmacSHA512(key, data []byte) ([]byte, []byte) {
+	h := hmac.New(sha512.New, key)
+	h.Write(data)
+	sum := h.Sum(nil)
+	return sum[:32], sum[32:]
+}
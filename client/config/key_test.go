@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/perun-network/perun-credential-payment/client/config"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestDeriveKey(t *testing.T) {
+	key, err := config.DeriveKey(testMnemonic, "", config.DefaultDerivationPath)
+	require.NoError(t, err)
+	require.Equal(t,
+		common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+		crypto.PubkeyToAddress(key.PublicKey))
+
+	key, err = config.DeriveKey(testMnemonic, "", "m/44'/60'/0'/0/1")
+	require.NoError(t, err)
+	require.Equal(t,
+		common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+		crypto.PubkeyToAddress(key.PublicKey))
+
+	_, err = config.DeriveKey("test test test", "", config.DefaultDerivationPath)
+	require.Error(t, err)
+}
+
+func TestKeySource_Mnemonic(t *testing.T) {
+	t.Setenv("CREDPAY_TEST_MNEMONIC", testMnemonic)
+	src := config.KeySource{Mnemonic: &config.MnemonicSource{
+		Phrase: config.Secret{Env: "CREDPAY_TEST_MNEMONIC"},
+	}}
+	key, err := src.PrivateKey()
+	require.NoError(t, err)
+	require.Equal(t,
+		common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+		crypto.PubkeyToAddress(key.PublicKey))
+}
+
+func TestKeySource_Keystore(t *testing.T) {
+	require := require.New(t)
+	dir := t.TempDir()
+	priv, err := crypto.GenerateKey()
+	require.NoError(err)
+	key := &keystore.Key{
+		Address:    crypto.PubkeyToAddress(priv.PublicKey),
+		PrivateKey: priv,
+	}
+	b, err := keystore.EncryptKey(key, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(err)
+	ksFile, pwFile := filepath.Join(dir, "keystore.json"), filepath.Join(dir, "password")
+	require.NoError(os.WriteFile(ksFile, b, 0o600))
+	require.NoError(os.WriteFile(pwFile, []byte("secret\n"), 0o600))
+
+	src := config.KeySource{Keystore: &config.KeystoreSource{
+		File:     ksFile,
+		Password: config.Secret{File: pwFile},
+	}}
+	got, err := src.PrivateKey()
+	require.NoError(err)
+	require.Equal(priv.D, got.D)
+
+	src.Keystore.Password = config.Secret{}
+	_, err = src.PrivateKey()
+	require.Error(err)
+}
+
+func TestKeySource_Exclusive(t *testing.T) {
+	src := config.KeySource{File: "key", Mnemonic: &config.MnemonicSource{}}
+	_, err := src.PrivateKey()
+	require.Error(t, err)
+}
@@ -2,14 +2,18 @@ package connection
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/perun-network/perun-credential-payment/app"
 	"github.com/perun-network/perun-credential-payment/app/data"
 	"github.com/perun-network/perun-credential-payment/pkg/atomic"
-	ewallet "perun.network/go-perun/backend/ethereum/wallet/simple"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/client"
 	"perun.network/go-perun/wallet"
@@ -34,17 +38,23 @@ type ConnectionRequest struct {
 	p        *ChannelProposal
 	acc      wallet.Address
 	registry *Registry
+	chainID  *big.Int
+	events   *Feed
 }
 
 func NewConnectionRequest(
 	p *ChannelProposal,
 	acc wallet.Address,
 	registry *Registry,
+	chainID *big.Int,
+	events *Feed,
 ) *ConnectionRequest {
 	return &ConnectionRequest{
 		p:        p,
 		acc:      acc,
 		registry: registry,
+		chainID:  chainID,
+		events:   events,
 	}
 }
 
@@ -58,8 +68,10 @@ func (r *ConnectionRequest) Accept(ctx context.Context) (*Connection, error) {
 	if err != nil {
 		return nil, fmt.Errorf("accepting channel: %w", err)
 	}
-	conn := NewConnection(ch)
+	conn := NewConnection(ch, r.chainID)
+	conn.SetEventFeed(r.events)
 	r.registry.Add(conn)
+	r.events.Emit(&ConnectionOpened{ChannelEvent{conn.ID()}, r.Peer()})
 
 	h := NewEventHandler(conn)
 	go func() {
@@ -72,67 +84,286 @@ func (r *ConnectionRequest) Accept(ctx context.Context) (*Connection, error) {
 	return conn, nil
 }
 
+// DefaultOfferValidity is the default number of channel versions for which an
+// offer can be answered by the issuer.
+const DefaultOfferValidity = 10
+
 type Connection struct {
 	*client.Channel
-	sigs         *sigReg
-	credRequests chan *CredentialRequest
-	disputed     *atomic.Bool
-	concludable  *atomic.Bool
-	concluded    *atomic.Bool
+	sigs          *sigReg
+	credRequests  chan *CredentialRequest
+	quotes        chan *Quote
+	disputed      *atomic.Bool
+	dispute       *Dispute
+	manager       *DisputeManager
+	final         chan struct{}
+	finalOnce     sync.Once
+	offerValidity uint64
+	sigScheme     app.SigScheme
+	chainID       *big.Int
+
+	restoredRequests     []*AsyncCredentials
+	restoredCredRequests []*CredentialRequest
+	issuanceLog          IssuanceLog
+	events               *Feed
+	registry             *Registry
 }
 
-func NewConnection(ch *client.Channel) *Connection {
-	return &Connection{
-		Channel:      ch,
-		sigs:         newSigReg(),
-		credRequests: make(chan *CredentialRequest),
-		disputed:     atomic.NewBool(false),
-		concludable:  atomic.NewBool(false),
-		concluded:    atomic.NewBool(false),
+// NewConnection creates a connection for the given channel. The chain ID
+// identifies the chain on which the channel is funded.
+func NewConnection(ch *client.Channel, chainID *big.Int) *Connection {
+	c := &Connection{
+		Channel:       ch,
+		sigs:          newSigReg(),
+		credRequests:  make(chan *CredentialRequest),
+		quotes:        make(chan *Quote, 1),
+		disputed:      atomic.NewBool(false),
+		dispute:       newDispute(),
+		final:         make(chan struct{}),
+		offerValidity: DefaultOfferValidity,
+		sigScheme:     app.SchemeHash,
+		chainID:       chainID,
+	}
+	c.manager = newDisputeManager(c)
+	return c
+}
+
+// RestoreConnection creates a connection for a channel that was restored from
+// persistence. Callbacks are registered for the pending requests of our side,
+// see RestoredRequests, and the pending requests of the peer are available
+// through RestoredCredentialRequests.
+func RestoreConnection(ch *client.Channel, chainID *big.Int) (*Connection, error) {
+	c := NewConnection(ch, chainID)
+	for _, o := range data.PendingOffers(c.State().Data) {
+		offer := o
+		if offer.Buyer != uint16(c.Idx()) {
+			c.restoredCredRequests = append(c.restoredCredRequests, &CredentialRequest{
+				offer:    &offer,
+				accepted: true,
+				conn:     c,
+			})
+			continue
+		}
+
+		callback, err := c.sigs.RegisterCallback(offer.ID)
+		if err != nil {
+			return nil, fmt.Errorf("registering callback for offer %d: %w", offer.ID, err)
+		}
+		c.restoredRequests = append(c.restoredRequests, &AsyncCredentials{sigRegCallback: callback, id: offer.ID})
 	}
+	return c, nil
+}
+
+// RestoredRequests returns the credential requests of our side that were
+// pending when the connection was restored.
+func (c *Connection) RestoredRequests() []*AsyncCredentials {
+	return c.restoredRequests
 }
 
+// RestoredCredentialRequests returns the credential requests of the peer that
+// were already accepted but not answered when the connection was restored.
+func (c *Connection) RestoredCredentialRequests() []*CredentialRequest {
+	return c.restoredCredRequests
+}
+
+// SetEventFeed sets the feed to which the connection emits its events.
+func (c *Connection) SetEventFeed(f *Feed) {
+	c.events = f
+}
+
+// channelEvent returns the channel event of the connection.
+func (c *Connection) channelEvent() ChannelEvent {
+	return ChannelEvent{ChannelID: c.ID()}
+}
+
+// Disputed returns whether the channel is disputed on-chain or an update was
+// forced by our side.
 func (c *Connection) Disputed() bool {
-	return c.disputed.Value()
+	return c.disputed.Value() || c.dispute.Phase() != PhaseNone
+}
+
+// Dispute returns the dispute state of the connection.
+func (c *Connection) Dispute() *Dispute {
+	return c.dispute
 }
 
+// DisputeManager returns the manager that drives the on-chain dispute
+// resolution of the connection.
+func (c *Connection) DisputeManager() *DisputeManager {
+	return c.manager
+}
+
+// StopWatching stops the goroutines tracking the dispute of the connection.
+func (c *Connection) StopWatching() {
+	c.dispute.Stop()
+}
+
+// SetOfferValidity sets the number of channel versions for which the offers
+// of subsequent credential requests can be answered by the issuer.
+func (c *Connection) SetOfferValidity(v uint64) {
+	c.offerValidity = v
+}
+
+// SetSignatureScheme sets the scheme in which the credentials of subsequent
+// requests and quotes are signed. The default is app.SchemeHash.
+func (c *Connection) SetSignatureScheme(s app.SigScheme) {
+	c.sigScheme = s
+}
+
+// RequestCredential requests the credential for the given document from the
+// given issuer. The price is paid in the asset held by the given asset holder.
+// If the issuer rejects the request, the returned AsyncCredential resolves to
+// a *RequestRejectedError.
 func (c *Connection) RequestCredential(
 	ctx context.Context,
 	doc []byte,
+	asset common.Address,
 	price channel.Bal,
 	issuer common.Address,
 ) (*AsyncCredential, error) {
-	// Compute hash.
-	h := app.ComputeDocumentHash(doc)
-
-	callback, err := c.sigs.RegisterCallback(h, issuer)
+	creds, err := c.RequestCredentials(ctx, [][]byte{doc}, asset, []channel.Bal{price}, issuer)
 	if err != nil {
 		return nil, err
 	}
+	return &AsyncCredential{creds}, nil
+}
+
+// RequestCredentials requests the credentials for the given documents from
+// the given issuer in a single channel update. The i-th document is priced at
+// the i-th price. The issuer either issues all credentials or none. If the
+// issuer rejects the request, the returned AsyncCredentials resolves to a
+// *RequestRejectedError. Several requests can be pending at the same time.
+func (c *Connection) RequestCredentials(
+	ctx context.Context,
+	docs [][]byte,
+	asset common.Address,
+	prices []channel.Bal,
+	issuer common.Address,
+) (*AsyncCredentials, error) {
+	return c.RequestSaltedCredentials(ctx, docs, nil, asset, prices, issuer)
+}
+
+// RequestSaltedCredentials is like RequestCredentials, but the offer commits
+// to the i-th document with the i-th salt instead of containing the plain
+// document hash. This way, the channel state does not reveal the documents
+// during a dispute. The salts must be shared with the issuer off-chain.
+func (c *Connection) RequestSaltedCredentials(
+	ctx context.Context,
+	docs [][]byte,
+	salts [][]byte,
+	asset common.Address,
+	prices []channel.Bal,
+	issuer common.Address,
+) (*AsyncCredentials, error) {
+	return c.RequestThresholdCredentials(ctx, docs, salts, asset, prices, []common.Address{issuer}, 1)
+}
+
+// RequestThresholdCredentials is like RequestSaltedCredentials, but each
+// credential must be signed by at least `threshold` of the given issuers. The
+// channel peer collects the signatures of the other issuers off-chain.
+func (c *Connection) RequestThresholdCredentials(
+	ctx context.Context,
+	docs [][]byte,
+	salts [][]byte,
+	asset common.Address,
+	prices []channel.Bal,
+	issuers []common.Address,
+	threshold int,
+) (*AsyncCredentials, error) {
+	if len(docs) == 0 || len(docs) != len(prices) {
+		return nil, fmt.Errorf("invalid number of documents or prices")
+	} else if salts != nil && len(salts) != len(docs) {
+		return nil, fmt.Errorf("invalid number of salts")
+	} else if threshold < 1 || threshold > len(issuers) {
+		return nil, fmt.Errorf("invalid threshold: %d of %d", threshold, len(issuers))
+	}
+
+	// Compute commitments.
+	hs := make([]app.Hash, len(docs))
+	for i, doc := range docs {
+		hs[i] = app.ComputeSaltedDocumentHash(doc, saltAt(salts, i))
+	}
+
+	return c.requestOffer(ctx, func(s *channel.State) (*data.Offer, error) {
+		assetIdx, ok := s.Allocation.AssetIndex(ethwallet.AsWalletAddr(asset))
+		if !ok {
+			return nil, fmt.Errorf("unknown asset: %v", asset)
+		}
+
+		// The offer is created with the next version, which also serves as
+		// its ID.
+		offerVersion := s.Version + 1
+		return &data.Offer{
+			ID:         offerVersion,
+			Issuers:    append([]common.Address(nil), issuers...),
+			Threshold:  uint16(threshold),
+			DataHashes: hs,
+			Prices:     prices,
+			Asset:      uint16(assetIdx),
+			Buyer:      uint16(c.Idx()),
+			Expiry:     offerVersion + c.offerValidity,
+			CreatedAt:  uint64(time.Now().Unix()),
+			Scheme:     c.sigScheme,
+			ChainID:    new(big.Int).Set(c.chainID),
+		}, nil
+	})
+}
+
+// requestOffer adds the offer created by `mkOffer` to the pending offers and
+// registers a callback for the signatures on the offered documents. If the
+// offer accepts the current quote, the quote is removed.
+func (c *Connection) requestOffer(
+	ctx context.Context,
+	mkOffer func(*channel.State) (*data.Offer, error),
+) (*AsyncCredentials, error) {
+	var (
+		offer    *data.Offer
+		callback sigRegCallback
+	)
 
 	// Perform request.
-	err = c.UpdateBy(ctx, func(s *channel.State) error {
-		s.Data = &data.Offer{
-			Issuer:   issuer,
-			DataHash: h,
-			Price:    price,
-			Buyer:    uint16(c.Idx()),
+	err := c.UpdateBy(ctx, func(s *channel.State) error {
+		var err error
+		offer, err = mkOffer(s)
+		if err != nil {
+			return err
+		}
+
+		// The callback is registered before the update is proposed so that
+		// the response of the issuer cannot be missed.
+		callback, err = c.sigs.RegisterCallback(offer.ID)
+		if err != nil {
+			return err
+		}
+
+		offers := append(data.PendingOffers(s.Data), *offer)
+		if q, ok := s.Data.(*data.Quote); ok && q.Offer.Equal(offer) {
+			s.Data = &data.Pending{Offers: offers}
+		} else {
+			s.Data = data.WithPendingOffers(s.Data, offers)
 		}
 		return nil
 	})
-	if err != nil {
+	if callback == nil {
+		return nil, fmt.Errorf("updating channel: %w", err)
+	} else if rejected := (client.PeerRejectedError{}); errors.As(err, &rejected) {
+		// The request was rejected by the issuer.
+		c.sigs.Cancel(offer.ID, &RequestRejectedError{Reason: rejected.Reason})
+	} else if err != nil {
+		c.sigs.Cancel(offer.ID, err)
 		return nil, fmt.Errorf("updating channel: %w", err)
 	}
 
-	return &AsyncCredential{callback}, nil
+	return &AsyncCredentials{sigRegCallback: callback, id: offer.ID}, nil
 }
 
-func (c *Connection) addCredentialRequest(offer *data.Offer) chan CredentialRequestResponse {
+func (c *Connection) addCredentialRequest(offer *data.Offer, quoted bool) chan CredentialRequestResponse {
 	response := make(chan CredentialRequestResponse)
 	c.credRequests <- &CredentialRequest{
-		resp:  response,
-		offer: offer,
-		conn:  c,
+		resp:   response,
+		offer:  offer,
+		quoted: quoted,
+		conn:   c,
 	}
 	return response
 }
@@ -146,81 +377,263 @@ func (c *Connection) NextCredentialRequest(ctx context.Context) (*CredentialRequ
 	}
 }
 
-func (c *Connection) addSignature(sig app.Signature, h app.Hash, issuer common.Address, responder *client.UpdateResponder) {
-	c.sigs.Push(sig, h, issuer, responder)
+// collectSignatures signs the documents of the given offer with the given
+// signer, if it is one of the issuers or their delegate, and verifies the
+// given co-signatures. It returns the signatures by signer index and
+// app.ErrThresholdNotMet if they do not meet the threshold of the offer.
+func (c *Connection) collectSignatures(offer *data.Offer, signer app.Signer, cosigs []*CoSignature) (map[uint16]*CoSignature, error) {
+	issuer, _ := app.IssuerOf(signer)
+	if _, ok := issuerIndex(offer, issuer); ok {
+		own, err := SignMessages(signer, c.credentialMessages(offer, issuer))
+		if err != nil {
+			return nil, err
+		}
+		cosigs = append([]*CoSignature{own}, cosigs...)
+	}
+
+	sigs := make(map[uint16]*CoSignature)
+	for _, cosig := range cosigs {
+		j, ok := issuerIndex(offer, cosig.Issuer)
+		if !ok {
+			return nil, fmt.Errorf("co-signer is not an issuer: %v", cosig.Issuer)
+		} else if err := c.verifyCoSignature(offer, cosig); err != nil {
+			return nil, fmt.Errorf("verifying co-signature of %v: %w", cosig.Issuer, err)
+		}
+		sigs[j] = cosig
+	}
+	if len(sigs) < int(offer.Threshold) {
+		return nil, app.ErrThresholdNotMet
+	}
+	return sigs, nil
 }
 
-func (c *Connection) issueCredential(ctx context.Context, offer *data.Offer, acc *ewallet.Account) error {
+// issueCredential issues the credentials of the given offer with the given
+// signatures, see collectSignatures. It returns whether the update was
+// enforced on-chain.
+func (c *Connection) issueCredential(ctx context.Context, offer *data.Offer, sigs map[uint16]*CoSignature) (forced bool, err error) {
+	// The app expects the signers in ascending order.
+	signers := make([]uint16, 0, len(sigs))
+	for j := range sigs {
+		signers = append(signers, j)
+	}
+	sort.Slice(signers, func(a, b int) bool { return signers[a] < signers[b] })
+
 	up := func(s *channel.State) error {
 		// Check inputs against current state.
-		curOffer, ok := s.Data.(*data.Offer)
+		offers := data.PendingOffers(s.Data)
+		i, ok := data.FindOffer(offers, offer.ID)
 		if !ok {
-			return fmt.Errorf("data has wrong type: %T", s.Data)
-		} else if !curOffer.Equal(offer) {
-			return fmt.Errorf("unequal offers: got %v, expected %v", curOffer, offer)
-		} else if addr := acc.Account.Address; offer.Issuer != addr {
-			return fmt.Errorf("unequal addresses: got %v, expected %v", addr, offer.Issuer)
+			return fmt.Errorf("offer not pending: %d", offer.ID)
+		} else if !offers[i].Equal(offer) {
+			return fmt.Errorf("unequal offers: got %v, expected %v", offers[i], offer)
 		}
 
-		// Sign.
-		sig, err := app.SignHash(acc, offer.DataHash)
-		if err != nil {
-			return fmt.Errorf("signing hash: %w", err)
+		cert := data.Cert{
+			ID:      offer.ID,
+			Signers: signers,
+			Pending: data.RemoveOffer(offers, i),
+		}
+		for _, j := range signers {
+			cert.Delegations = append(cert.Delegations, sigs[j].Delegation)
+		}
+		for i := range offer.DataHashes {
+			for _, j := range signers {
+				cert.Signatures = append(cert.Signatures, sigs[j].Signatures[i])
+			}
 		}
 
 		// Update state data.
-		var cert data.Cert
-		copy(cert.Signature[:], sig[:])
 		s.Data = &cert
 
 		// Update balances.
-		asset := s.Allocation.Assets[app.AssetIdx]
-		s.Allocation.SubFromBalance(channel.Index(offer.Buyer), asset, offer.Price)
-		s.Allocation.AddToBalance(c.Idx(), asset, offer.Price)
+		asset := s.Allocation.Assets[offer.Asset]
+		price := offer.TotalPrice()
+		s.Allocation.SubFromBalance(channel.Index(offer.Buyer), asset, price)
+		s.Allocation.AddToBalance(c.Idx(), asset, price)
 
 		return nil
 	}
 
-	err := c.UpdateBy(ctx, up)
+	// The payment can only be claimed until the offer expires.
+	if c.State().Version >= offer.Expiry {
+		return false, app.ErrOfferExpired
+	}
+
+	offLedgerErr, err := c.updateOrForce(ctx, up)
+	if rejected := (client.PeerRejectedError{}); errors.As(offLedgerErr, &rejected) {
+		c.events.Emit(&PaymentRejected{c.channelEvent(), offer.ID, rejected.Reason})
+	} else if offLedgerErr == nil && err == nil {
+		c.events.Emit(&PaymentAccepted{c.channelEvent(), offer.ID})
+	}
 	if err != nil {
-		c.Log().Warnf("Failed to update channel off-ledger: %v", err)
-		c.Log().Warnf("Forcing update on-ledger")
-
-		c.disputed.SetValue(true)
-		err := c.ForceUpdate(ctx, func(s *channel.State) {
-			err := up(s)
-			if err != nil {
-				c.Log().Warnf("Updating channel state: %v", err)
-			}
-		})
+		return false, err
+	}
+	forced = offLedgerErr != nil
+	c.events.Emit(&CredentialIssued{c.channelEvent(), offer.ID, forced})
+	return forced, nil
+}
+
+// credentialMessages returns the messages that the given issuer signs for the
+// documents of the given offer.
+func (c *Connection) credentialMessages(offer *data.Offer, issuer common.Address) []*app.CredentialMessage {
+	b := c.binding(offer)
+	msgs := make([]*app.CredentialMessage, len(offer.DataHashes))
+	for i, docHash := range offer.DataHashes {
+		msgs[i] = app.NewCredentialMessage(offer, docHash, issuer, b)
+	}
+	return msgs
+}
+
+// verifyCoSignature checks that the co-signature contains a valid signature
+// by its issuer, or its delegate, on each document of the given offer.
+func (c *Connection) verifyCoSignature(offer *data.Offer, cosig *CoSignature) error {
+	dom := app.Domain{ChainID: offer.ChainID, Contract: c.binding(offer).Contract}
+	key, err := app.SigningKey(cosig.Issuer, &cosig.Delegation, dom, offer.CreatedAt)
+	if err != nil {
+		return err
+	}
+	msgs := c.credentialMessages(offer, cosig.Issuer)
+	if len(cosig.Signatures) != len(msgs) {
+		return fmt.Errorf("wrong number of signatures")
+	}
+	for i, m := range msgs {
+		h, err := m.Hash()
 		if err != nil {
-			return fmt.Errorf("forcing update: %w", err)
+			return fmt.Errorf("computing credential hash: %w", err)
+		}
+		if err := app.VerifySig(cosig.Signatures[i], h, key); err != nil {
+			return fmt.Errorf("signature %d: %w", i, err)
 		}
 	}
-
 	return nil
 }
 
-func (c *Connection) TryClose(ctx context.Context, attempts int) error {
-	for i := 1; i <= attempts; i++ {
-		err := c.Close(ctx)
-		if err == nil {
-			return nil
-		} else {
-			c.Log().Warnf("Failed to close channel (attempt %d): %v", i, err)
+// issuerIndex returns the index of the given issuer in the offer.
+func issuerIndex(offer *data.Offer, issuer common.Address) (uint16, bool) {
+	for j, a := range offer.Issuers {
+		if a == issuer {
+			return uint16(j), true
 		}
 	}
-	return fmt.Errorf("Failed to close channel in %d attempts", attempts)
+	return 0, false
 }
 
-func (c *Connection) Close(ctx context.Context) error {
-	if c.Disputed() {
-		// If there is a dispute, we wait until the channel is concludable.
-		err := c.WaitConcludadable(ctx)
+// binding returns the binding of the credentials of the given offer.
+func (c *Connection) binding(offer *data.Offer) app.Binding {
+	return app.Binding{
+		ChannelID: c.ID(),
+		Holder:    ethwallet.AsEthAddr(c.Params().Parts[offer.Buyer]),
+		Contract:  ethwallet.AsEthAddr(c.Params().App.Def()),
+	}
+}
+
+// CancelRequest cancels the pending credential request with the given offer
+// ID. The request can only be cancelled once the offer expired, which requires
+// that the channel version advanced beyond the expiry of the offer. As the
+// holder cannot advance the version up to the expiry itself, this depends on
+// updates by the issuer. If the peer does not agree to the cancellation, it is
+// enforced on-ledger. To release the funds of a request that did not expire
+// yet, for example if the issuer does not respond, the channel must be closed.
+func (c *Connection) CancelRequest(ctx context.Context, id uint64) error {
+	offers := data.PendingOffers(c.State().Data)
+	i, ok := data.FindOffer(offers, id)
+	if !ok {
+		return ErrNoPendingRequest
+	}
+	offer := offers[i]
+	if c.State().Version < offer.Expiry {
+		return app.ErrOfferNotExpired
+	}
+
+	up := func(s *channel.State) error {
+		offers := data.PendingOffers(s.Data)
+		i, ok := data.FindOffer(offers, id)
+		if !ok {
+			return fmt.Errorf("offer not pending: %d", id)
+		} else if !offers[i].Equal(&offer) {
+			return fmt.Errorf("unequal offers: got %v, expected %v", offers[i], offer)
+		}
+
+		// Update state data. The balances remain unchanged.
+		s.Data = data.WithPendingOffers(s.Data, data.RemoveOffer(offers, i))
+		return nil
+	}
+
+	_, err := c.updateOrForce(ctx, up)
+	if err != nil {
+		return err
+	}
+
+	c.sigs.Cancel(id, ErrRequestCancelled)
+	return nil
+}
+
+// errDisputed is the off-ledger error of updateOrForce if the channel is
+// already disputed.
+var errDisputed = errors.New("channel disputed")
+
+// updateOrForce attempts to update the channel off-ledger. If the peer
+// rejects the update or does not respond in time, or if the channel is already
+// disputed, the update is enforced on-ledger. Errors of the update function
+// and other errors of the off-ledger attempt are returned without forcing the
+// update. It returns the error of the off-ledger attempt, which is nil if the
+// update succeeded off-ledger, and the error of the update.
+func (c *Connection) updateOrForce(ctx context.Context, up func(*channel.State) error) (offLedgerErr, err error) {
+	if err := up(c.State().Clone()); err != nil {
+		return nil, err
+	}
+
+	offLedgerErr = errDisputed
+	if !c.Disputed() {
+		offLedgerErr = c.UpdateBy(ctx, up)
+		if offLedgerErr == nil {
+			return nil, nil
+		} else if !peerFailed(offLedgerErr) {
+			return nil, offLedgerErr
+		}
+		c.Log().Warnf("Failed to update channel off-ledger: %v", offLedgerErr)
+	}
+
+	c.Log().Warnf("Forcing update on-ledger")
+	err = c.manager.ForceUpdate(ctx, func(s *channel.State) {
+		err := up(s)
 		if err != nil {
-			return fmt.Errorf("waiting for channel concludable: %w", err)
+			c.Log().Warnf("Updating channel state: %v", err)
 		}
-	} else if !c.State().IsFinal {
+	})
+	if err != nil {
+		return offLedgerErr, fmt.Errorf("forcing update: %w", err)
+	}
+
+	return offLedgerErr, nil
+}
+
+// peerFailed returns whether the error indicates that the peer rejected an
+// update or did not respond in time.
+func peerFailed(err error) bool {
+	var rejected client.PeerRejectedError
+	var timedOut client.RequestTimedOutError
+	return errors.As(err, &rejected) || errors.As(err, &timedOut)
+}
+
+// TryClose closes the connection like Close, but makes at most the given
+// number of attempts to settle the channel.
+func (c *Connection) TryClose(ctx context.Context, attempts int) error {
+	b := c.manager.Backoff()
+	b.Attempts = attempts
+	return c.close(ctx, b)
+}
+
+// Close finalizes the channel off-ledger, or waits for the dispute to become
+// concludable if the channel is disputed, and then settles the channel. Failed
+// on-ledger steps are retried according to the backoff of the dispute
+// manager.
+func (c *Connection) Close(ctx context.Context) error {
+	return c.close(ctx, c.manager.Backoff())
+}
+
+func (c *Connection) close(ctx context.Context, b Backoff) error {
+	if !c.Disputed() && !c.State().IsFinal {
 		// If there is no dispute, we attempt to finalize the channel.
 		err := c.UpdateBy(ctx, func(s *channel.State) error {
 			s.Data = &data.DefaultData{}
@@ -229,37 +642,43 @@ func (c *Connection) Close(ctx context.Context) error {
 		})
 		if err != nil {
 			c.Log().Warnf("Failed to finalize channel off-ledger: %v", err)
+		} else {
+			c.markFinal()
 		}
 	}
 
-	err := c.Settle(ctx, false)
+	err := c.manager.settle(ctx, b)
 	if err != nil {
 		return fmt.Errorf("settling: %w", err)
 	}
 
+	c.dispute.Stop()
+	if c.registry != nil {
+		c.registry.Remove(c.ID())
+	}
+	c.events.Emit(&Settled{c.channelEvent()})
 	return nil
 }
 
-func (c *Connection) WaitConcludadable(ctx context.Context) error {
-	return waitCondition(ctx, func() bool {
-		return c.State().IsFinal || c.concludable.Value()
-	})
+// markFinal records that the channel state was finalized off-ledger.
+func (c *Connection) markFinal() {
+	c.finalOnce.Do(func() { close(c.final) })
 }
 
-func waitCondition(ctx context.Context, cond func() bool) error {
-	const tick = 500 * time.Millisecond
-
-loop:
-	for {
-		select {
-		case <-time.After(tick):
-			if cond() {
-				break loop
-			}
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+// WaitConcludadable waits until the channel state is final or the dispute of
+// the channel is concludable.
+func (c *Connection) WaitConcludadable(ctx context.Context) error {
+	if c.State().IsFinal {
+		return nil
+	}
+	select {
+	case <-c.final:
+		return nil
+	case <-c.dispute.Concludable():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.dispute.Done():
+		return ErrDisputeStopped
 	}
-
-	return nil
 }
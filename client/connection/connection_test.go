@@ -0,0 +1,17 @@
+package connection
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"perun.network/go-perun/client"
+)
+
+func TestPeerFailed(t *testing.T) {
+	require.True(t, peerFailed(errors.WithStack(client.PeerRejectedError{ItemType: "channel update", Reason: "no"})))
+	require.True(t, peerFailed(fmt.Errorf("updating: %w", client.RequestTimedOutError("timeout"))))
+	require.False(t, peerFailed(errors.New("offer not pending: 1")))
+	require.False(t, peerFailed(errors.WithMessage(errors.New("boom"), "sending update")))
+}
@@ -18,10 +18,20 @@ func NewRegistry() *Registry {
 	}
 }
 
+// Add adds the connection to the registry. The connection removes itself
+// from the registry once it is settled.
 func (r *Registry) Add(conn *Connection) {
 	r.mu.Lock()
 	r.r[conn.ID()] = conn
 	r.mu.Unlock()
+	conn.registry = r
+}
+
+// Remove removes the connection with the given ID from the registry.
+func (r *Registry) Remove(id channel.ID) {
+	r.mu.Lock()
+	delete(r.r, id)
+	r.mu.Unlock()
 }
 
 func (r *Registry) ForID(id channel.ID) (*Connection, bool) {
@@ -30,3 +40,14 @@ func (r *Registry) ForID(id channel.ID) (*Connection, bool) {
 	r.mu.RUnlock()
 	return c, ok
 }
+
+// All returns all connections of the registry.
+func (r *Registry) All() []*Connection {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	conns := make([]*Connection, 0, len(r.r))
+	for _, c := range r.r {
+		conns = append(conns, c)
+	}
+	return conns
+}
@@ -3,46 +3,147 @@ package connection
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/perun-network/perun-credential-payment/app"
 	"github.com/perun-network/perun-credential-payment/app/data"
-	"perun.network/go-perun/backend/ethereum/wallet/simple"
 	"perun.network/go-perun/client"
 )
 
+var (
+	ErrNoPendingRequest = errors.New("no pending credential request")
+	ErrRequestCancelled = errors.New("credential request cancelled")
+)
+
 type CredentialRequest struct {
-	resp  chan CredentialRequestResponse
-	offer *data.Offer
-	conn  *Connection
+	resp     chan CredentialRequestResponse
+	offer    *data.Offer
+	quoted   bool
+	accepted bool // Whether the offer is already part of the channel state.
+	conn     *Connection
+}
+
+// ID returns the ID of the offer of the credential request.
+func (r *CredentialRequest) ID() uint64 {
+	return r.offer.ID
+}
+
+// Quoted returns whether the request accepts the quote that was previously
+// sent to the holder.
+func (r *CredentialRequest) Quoted() bool {
+	return r.quoted
+}
+
+// DataHashes returns the hashes of the requested documents.
+func (r *CredentialRequest) DataHashes() []app.Hash {
+	return append([]app.Hash(nil), r.offer.DataHashes...)
+}
+
+// Prices returns the offered prices of the requested documents.
+func (r *CredentialRequest) Prices() []*big.Int {
+	prices := make([]*big.Int, len(r.offer.Prices))
+	for i, p := range r.offer.Prices {
+		prices[i] = new(big.Int).Set(p)
+	}
+	return prices
+}
+
+// CheckDoc checks that the request is for the given document only. The salt
+// is the salt of the document commitment and may be empty.
+func (r *CredentialRequest) CheckDoc(doc, salt []byte) error {
+	return r.CheckDocs([][]byte{doc}, [][]byte{salt})
 }
 
-func (r *CredentialRequest) CheckDoc(doc []byte) error {
-	docHash := app.ComputeDocumentHash(doc)
-	if !bytes.Equal(docHash[:], r.offer.DataHash[:]) {
-		return fmt.Errorf("wrong document")
+// CheckDocs checks that the request is for the given documents. The i-th salt
+// is the salt of the commitment to the i-th document. If salts is nil, the
+// documents are expected to be unsalted.
+func (r *CredentialRequest) CheckDocs(docs, salts [][]byte) error {
+	if len(docs) != len(r.offer.DataHashes) {
+		return fmt.Errorf("wrong number of documents")
+	} else if salts != nil && len(salts) != len(docs) {
+		return fmt.Errorf("wrong number of salts")
+	}
+	for i, doc := range docs {
+		docHash := app.ComputeSaltedDocumentHash(doc, saltAt(salts, i))
+		if !bytes.Equal(docHash[:], r.offer.DataHashes[i][:]) {
+			return fmt.Errorf("wrong document: %d", i)
+		}
 	}
 	return nil
 }
 
+// CheckPrice checks that the request is for a single document at the given
+// price.
 func (r *CredentialRequest) CheckPrice(p *big.Int) error {
-	if r.offer.Price.Cmp(p) != 0 {
-		return fmt.Errorf("wrong price")
+	return r.CheckPrices([]*big.Int{p})
+}
+
+// CheckPrices checks that the requested documents are offered at the given
+// prices.
+func (r *CredentialRequest) CheckPrices(ps []*big.Int) error {
+	if len(ps) != len(r.offer.Prices) {
+		return fmt.Errorf("wrong number of prices")
+	}
+	for i, p := range ps {
+		if r.offer.Prices[i].Cmp(p) != 0 {
+			return fmt.Errorf("wrong price: %d", i)
+		}
 	}
 	return nil
 }
 
-func (r *CredentialRequest) IssueCredential(ctx context.Context, acc *simple.Account) error {
-	errs := make(chan error)
-	r.resp <- &CredentialRequestResponseAccept{ctx, errs}
-	err := <-errs
+// Issuers returns the issuers of the request and the number of issuers that
+// must sign each credential.
+func (r *CredentialRequest) Issuers() ([]common.Address, int) {
+	return append([]common.Address(nil), r.offer.Issuers...), int(r.offer.Threshold)
+}
+
+// Messages returns the messages that the given issuer must sign, one per
+// requested document. Co-issuers sign these messages and return the
+// signatures as a CoSignature.
+func (r *CredentialRequest) Messages(issuer common.Address) ([]*app.CredentialMessage, error) {
+	if _, ok := issuerIndex(r.offer, issuer); !ok {
+		return nil, fmt.Errorf("not an issuer: %v", issuer)
+	}
+	return r.conn.credentialMessages(r.offer, issuer), nil
+}
+
+// IssueCredential accepts the request and issues the credentials signed by
+// the given signer.
+func (r *CredentialRequest) IssueCredential(ctx context.Context, signer app.Signer) error {
+	return r.IssueCoSignedCredential(ctx, signer, nil)
+}
+
+// IssueCoSignedCredential accepts the request and issues the credentials
+// signed by the given signer, if it is an issuer, and the given co-signers.
+// The payment goes to our side of the channel. The signatures must meet the
+// threshold of the request. They are checked before the request is accepted,
+// so that a request can still be rejected if they do not.
+func (r *CredentialRequest) IssueCoSignedCredential(ctx context.Context, signer app.Signer, cosigs []*CoSignature) error {
+	sigs, err := r.conn.collectSignatures(r.offer, signer, cosigs)
 	if err != nil {
-		return fmt.Errorf("accepting credential request: %w", err)
+		err = fmt.Errorf("collecting signatures: %w", err)
+		r.conn.recordIssuance(r.offer, false, err)
+		return err
+	}
+
+	if !r.accepted {
+		errs := make(chan error)
+		r.resp <- &CredentialRequestResponseAccept{ctx, errs}
+		if err := <-errs; err != nil {
+			err = fmt.Errorf("accepting credential request: %w", err)
+			r.conn.recordIssuance(r.offer, false, err)
+			return err
+		}
+		r.accepted = true
 	}
 
 	// Issue credential.
-	err = r.conn.issueCredential(ctx, r.offer, acc)
+	forced, err := r.conn.issueCredential(ctx, r.offer, sigs)
+	r.conn.recordIssuance(r.offer, forced, err)
 	if err != nil {
 		return fmt.Errorf("issueing credential: %w", err)
 	}
@@ -50,6 +151,46 @@ func (r *CredentialRequest) IssueCredential(ctx context.Context, acc *simple.Acc
 	return nil
 }
 
+// CoSignature holds the signatures of a co-issuer on the messages of a
+// credential request, one per requested document. If the delegation is not
+// zero, the signatures are by the delegated key of the issuer.
+type CoSignature struct {
+	Issuer     common.Address
+	Delegation data.Delegation
+	Signatures [][data.SigLen]byte
+}
+
+// SignMessages signs the given messages with the given signer. If the signer
+// is an *app.DelegatedSigner, it signs on behalf of its issuer.
+func SignMessages(signer app.Signer, msgs []*app.CredentialMessage) (*CoSignature, error) {
+	issuer, delegation := app.IssuerOf(signer)
+	cosig := &CoSignature{Issuer: issuer, Delegation: delegation}
+	for i, m := range msgs {
+		sig, err := signer.SignCredential(m)
+		if err != nil {
+			return nil, fmt.Errorf("signing message %d: %w", i, err)
+		}
+		cosig.Signatures = append(cosig.Signatures, sig)
+	}
+	return cosig, nil
+}
+
+// Reject rejects the credential request with the given reason. A request
+// that was restored from persistence was already accepted and cannot be
+// rejected.
+func (r *CredentialRequest) Reject(ctx context.Context, reason string) error {
+	if r.accepted {
+		return fmt.Errorf("credential request already accepted")
+	}
+	errs := make(chan error)
+	r.resp <- &CredentialRequestResponseReject{ctx, errs, reason}
+	err := <-errs
+	if err != nil {
+		return fmt.Errorf("rejecting credential request: %w", err)
+	}
+	return nil
+}
+
 type (
 	CredentialRequestResponse interface {
 		Context() context.Context
@@ -60,6 +201,12 @@ type (
 		ctx  context.Context
 		errs chan error
 	}
+
+	CredentialRequestResponseReject struct {
+		ctx    context.Context
+		errs   chan error
+		reason string
+	}
 )
 
 func (r *CredentialRequestResponseAccept) Context() context.Context {
@@ -70,20 +217,120 @@ func (r *CredentialRequestResponseAccept) Result() chan error {
 	return r.errs
 }
 
-type AsyncCredential struct {
+func (r *CredentialRequestResponseReject) Context() context.Context {
+	return r.ctx
+}
+
+func (r *CredentialRequestResponseReject) Result() chan error {
+	return r.errs
+}
+
+// RequestRejectedError indicates that the issuer rejected a credential
+// request.
+type RequestRejectedError struct {
+	Reason string
+}
+
+func (e *RequestRejectedError) Error() string {
+	return fmt.Sprintf("credential request rejected: %s", e.Reason)
+}
+
+type AsyncCredentials struct {
 	sigRegCallback
+	id uint64
 }
 
-func (c *AsyncCredential) Await(ctx context.Context) (*CredentialProposal, error) {
+// ID returns the ID of the offer of the credential request.
+func (c *AsyncCredentials) ID() uint64 {
+	return c.id
+}
+
+// Await waits for the credentials to be issued. It returns
+// ErrRequestCancelled if the request was cancelled and a
+// *RequestRejectedError if the request was rejected by the issuer.
+func (c *AsyncCredentials) Await(ctx context.Context) (*CredentialsProposal, error) {
 	select {
-	case prop := <-c.sigRegCallback:
-		return prop, nil
+	case r := <-c.sigRegCallback:
+		return r.Proposal, r.Err
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	}
 }
 
+type AsyncCredential struct {
+	creds *AsyncCredentials
+}
+
+// ID returns the ID of the offer of the credential request.
+func (c *AsyncCredential) ID() uint64 {
+	return c.creds.ID()
+}
+
+// Await waits for the credential to be issued. It returns ErrRequestCancelled
+// if the request was cancelled and a *RequestRejectedError if the request was
+// rejected by the issuer.
+func (c *AsyncCredential) Await(ctx context.Context) (*CredentialProposal, error) {
+	prop, err := c.creds.Await(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &CredentialProposal{
+		UpdateResponder: prop.UpdateResponder,
+		Signature:       prop.Signatures[0][0],
+		creds:           prop,
+	}, nil
+}
+
+// CredentialsProposal holds the signatures on the requested documents. The
+// signature Signatures[i][j] is by Signers[j], or its delegate given by
+// Delegations[j], on the i-th document in the order of the request. The
+// payment is completed by accepting the proposal.
+type CredentialsProposal struct {
+	*client.UpdateResponder
+	Signatures  [][]app.Signature
+	Signers     []common.Address
+	Delegations []data.Delegation
+	offer       *data.Offer
+	binding     app.Binding
+}
+
+// Credential returns the credential for the i-th requested document, which
+// is given by `doc` and the salt of its commitment.
+func (p *CredentialsProposal) Credential(i int, doc, salt []byte) *app.Credential {
+	return &app.Credential{
+		Document:    doc,
+		Salt:        salt,
+		Signatures:  p.Signatures[i],
+		Issuers:     p.Signers,
+		Delegations: p.Delegations,
+		Scheme:      p.offer.Scheme,
+		ChainID:     p.offer.ChainID,
+		CreatedAt:   p.offer.CreatedAt,
+		Binding:     p.binding,
+	}
+}
+
+// Price returns the price of the i-th requested document.
+func (p *CredentialsProposal) Price(i int) *big.Int {
+	return new(big.Int).Set(p.offer.Prices[i])
+}
+
 type CredentialProposal struct {
 	*client.UpdateResponder
 	Signature []byte
+	creds     *CredentialsProposal
+}
+
+// Credential returns the credential for the requested document, which is
+// given by `doc` and the salt of its commitment.
+func (p *CredentialProposal) Credential(doc, salt []byte) *app.Credential {
+	return p.creds.Credential(0, doc, salt)
+}
+
+// saltAt returns the i-th salt or nil if no salts are given.
+func saltAt(salts [][]byte, i int) []byte {
+	if salts == nil {
+		return nil
+	}
+	return salts[i]
 }
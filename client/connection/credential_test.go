@@ -0,0 +1,37 @@
+package connection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"github.com/stretchr/testify/require"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/backend/ethereum/wallet/simple"
+)
+
+func TestIssueCoSignedCredential_ThresholdNotMet(t *testing.T) {
+	require := require.New(t)
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	addr := ethwallet.AsWalletAddr(crypto.PubkeyToAddress(key.PublicKey))
+	acc, err := simple.NewWallet(key).Unlock(addr)
+	require.NoError(err)
+
+	// The signer is not one of the issuers of the request.
+	resp := make(chan CredentialRequestResponse, 1)
+	r := &CredentialRequest{
+		resp:  resp,
+		offer: &data.Offer{ID: 1, Issuers: []common.Address{{1}}, Threshold: 1},
+		conn:  &Connection{},
+	}
+	err = r.IssueCoSignedCredential(context.Background(), app.NewAccountSigner(acc.(*simple.Account)), nil)
+	require.ErrorIs(err, app.ErrThresholdNotMet)
+
+	// The request was not accepted and can still be rejected.
+	require.Empty(resp)
+	require.False(r.accepted)
+}
@@ -0,0 +1,272 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"perun.network/go-perun/channel"
+)
+
+// DisputePhase is the phase of an on-chain dispute of a connection.
+type DisputePhase int
+
+const (
+	// PhaseNone indicates that the channel is not disputed.
+	PhaseNone DisputePhase = iota
+	// PhaseRegistered indicates that a state was registered on-chain.
+	PhaseRegistered
+	// PhaseProgressed indicates that the registered state was progressed
+	// on-chain.
+	PhaseProgressed
+	// PhaseConcludable indicates that the timeout of the last progression
+	// elapsed and the channel can be concluded.
+	PhaseConcludable
+	// PhaseConcluded indicates that the channel was concluded on-chain.
+	PhaseConcluded
+)
+
+func (p DisputePhase) String() string {
+	switch p {
+	case PhaseNone:
+		return "none"
+	case PhaseRegistered:
+		return "registered"
+	case PhaseProgressed:
+		return "progressed"
+	case PhaseConcludable:
+		return "concludable"
+	case PhaseConcluded:
+		return "concluded"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrDisputeStopped is returned when waiting for a dispute phase that will
+// not be reached because the dispute was stopped.
+var ErrDisputeStopped = errors.New("dispute stopped")
+
+// Dispute tracks the on-chain dispute of a connection. It is driven by the
+// adjudicator events of the channel and exposes a channel for each phase,
+// which is closed once the phase is reached.
+type Dispute struct {
+	mu      sync.Mutex
+	phase   DisputePhase
+	version uint64
+	timeout time.Time
+
+	registered  chan struct{}
+	progressed  chan struct{}
+	concludable chan struct{}
+	concluded   chan struct{}
+
+	ctx         context.Context
+	cancel      context.CancelFunc
+	cancelTimer context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func newDispute() *Dispute {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispute{
+		registered:  make(chan struct{}),
+		progressed:  make(chan struct{}),
+		concludable: make(chan struct{}),
+		concluded:   make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Registered returns a channel that is closed once a state is registered.
+func (d *Dispute) Registered() <-chan struct{} { return d.registered }
+
+// Progressed returns a channel that is closed once the registered state is
+// progressed for the first time.
+func (d *Dispute) Progressed() <-chan struct{} { return d.progressed }
+
+// Concludable returns a channel that is closed once the channel can be
+// concluded. The channel is replaced if the dispute is progressed after it
+// became concludable.
+func (d *Dispute) Concludable() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.concludable
+}
+
+// Concluded returns a channel that is closed once the channel is concluded.
+func (d *Dispute) Concluded() <-chan struct{} { return d.concluded }
+
+// Done returns a channel that is closed when the dispute is stopped.
+func (d *Dispute) Done() <-chan struct{} { return d.ctx.Done() }
+
+// Phase returns the current phase of the dispute.
+func (d *Dispute) Phase() DisputePhase {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.phase
+}
+
+// Version returns the version of the last registered or progressed state.
+func (d *Dispute) Version() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.version
+}
+
+// Timeout returns the deadline of the current phase. For a registered state,
+// it is the end of the dispute period and for a progressed state, the time
+// from which the channel is concludable. It is zero if the channel is not
+// disputed or the timeout is unknown.
+func (d *Dispute) Timeout() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.timeout
+}
+
+// Wait waits until the dispute reaches the given phase.
+func (d *Dispute) Wait(ctx context.Context, p DisputePhase) error {
+	var c <-chan struct{}
+	switch p {
+	case PhaseNone:
+		return nil
+	case PhaseRegistered:
+		c = d.registered
+	case PhaseProgressed:
+		c = d.progressed
+	case PhaseConcludable:
+		c = d.Concludable()
+	case PhaseConcluded:
+		c = d.concluded
+	default:
+		return errors.New("unknown dispute phase")
+	}
+
+	select {
+	case <-c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.ctx.Done():
+		// The phase may have been reached right before stopping.
+		select {
+		case <-c:
+			return nil
+		default:
+			return ErrDisputeStopped
+		}
+	}
+}
+
+// Stop cancels the goroutines of the dispute. Phases that have not been
+// reached yet will not be reached anymore.
+func (d *Dispute) Stop() {
+	d.mu.Lock()
+	d.cancel()
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// register handles the registration of a state and starts waiting for the
+// channel to become concludable, which is the given duration of the
+// progression phase after the timeout if nobody progresses the state.
+func (d *Dispute) register(version uint64, timeout channel.Timeout, progression time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.phase > PhaseRegistered {
+		return
+	}
+	d.advance(PhaseRegistered)
+	d.version = version
+	d.timeout = timeoutTime(timeout)
+	d.startTimer(timeout, progression)
+}
+
+// progress handles the progression of the registered state and starts
+// waiting for the channel to become concludable. Stale progressions are
+// ignored. A progression after the channel became concludable moves the
+// dispute back to the progressed phase.
+func (d *Dispute) progress(version uint64, timeout channel.Timeout) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.phase >= PhaseConcluded || (d.phase > PhaseNone && version <= d.version) {
+		return
+	} else if d.phase == PhaseConcludable {
+		d.phase = PhaseProgressed
+		d.concludable = make(chan struct{})
+	}
+	d.advance(PhaseProgressed)
+	d.version = version
+	d.timeout = timeoutTime(timeout)
+	d.startTimer(timeout, 0)
+}
+
+// conclude handles the conclusion of the channel.
+func (d *Dispute) conclude(version uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.advance(PhaseConcluded)
+	d.version = version
+	d.stopTimer()
+}
+
+// startTimer starts waiting for the given timeout and the given delay after
+// it, replacing the timeout of a previous registration or progression. d.mu
+// must be held.
+func (d *Dispute) startTimer(timeout channel.Timeout, delay time.Duration) {
+	d.stopTimer()
+	if d.ctx.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	d.cancelTimer = cancel
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		if err := timeout.Wait(ctx); err != nil {
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if ctx.Err() == nil {
+			d.advance(PhaseConcludable)
+		}
+	}()
+}
+
+// stopTimer cancels the current timeout wait. d.mu must be held.
+func (d *Dispute) stopTimer() {
+	if d.cancelTimer != nil {
+		d.cancelTimer()
+		d.cancelTimer = nil
+	}
+}
+
+// advance moves the dispute forward to the given phase and resolves the
+// channels of all phases up to it. It never moves the dispute backward.
+// d.mu must be held.
+func (d *Dispute) advance(p DisputePhase) {
+	if p <= d.phase {
+		return
+	}
+	for q := d.phase + 1; q <= p; q++ {
+		switch q {
+		case PhaseRegistered:
+			close(d.registered)
+		case PhaseProgressed:
+			close(d.progressed)
+		case PhaseConcludable:
+			close(d.concludable)
+		case PhaseConcluded:
+			close(d.concluded)
+		}
+	}
+	d.phase = p
+}
@@ -0,0 +1,73 @@
+package connection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"perun.network/go-perun/channel"
+)
+
+func TestDispute_Phases(t *testing.T) {
+	require := require.New(t)
+	d := newDispute()
+	defer d.Stop()
+
+	require.Equal(PhaseNone, d.Phase())
+	deadline := time.Now().Add(2 * time.Second)
+	d.register(1, &channel.TimeTimeout{Time: deadline}, time.Hour)
+	<-d.Registered()
+	require.Equal(PhaseRegistered, d.Phase())
+	require.Equal(deadline.Unix(), d.Timeout().Unix())
+
+	d.progress(2, &channel.TimeTimeout{Time: time.Now().Add(50 * time.Millisecond)})
+	require.Equal(PhaseProgressed, d.Phase())
+	require.EqualValues(2, d.Version())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(d.Wait(ctx, PhaseConcludable))
+
+	d.conclude(2)
+	require.NoError(d.Wait(ctx, PhaseConcluded))
+	d.register(3, &channel.TimeTimeout{Time: deadline}, 0)
+	require.Equal(PhaseConcluded, d.Phase())
+}
+
+func TestDispute_RegisterTimeout(t *testing.T) {
+	require := require.New(t)
+	d := newDispute()
+	defer d.Stop()
+
+	// Without progression, the channel becomes concludable after the
+	// timeout and the progression phase.
+	d.register(1, &channel.TimeTimeout{Time: time.Now().Add(20 * time.Millisecond)}, 30*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(d.Wait(ctx, PhaseConcludable))
+
+	// A late progression moves the dispute back.
+	d.progress(2, &channel.TimeTimeout{Time: time.Now().Add(time.Hour)})
+	require.Equal(PhaseProgressed, d.Phase())
+	require.EqualValues(2, d.Version())
+	select {
+	case <-d.Concludable():
+		t.Fatal("concludable after late progression")
+	default:
+	}
+
+	// Stale progressions are ignored.
+	d.progress(1, &channel.TimeTimeout{Time: time.Now()})
+	require.EqualValues(2, d.Version())
+}
+
+func TestDispute_Stop(t *testing.T) {
+	d := newDispute()
+	d.progress(1, &channel.TimeTimeout{Time: time.Now().Add(time.Hour)})
+	d.Stop()
+
+	err := d.Wait(context.Background(), PhaseConcludable)
+	require.ErrorIs(t, err, ErrDisputeStopped)
+	require.Equal(t, PhaseProgressed, d.Phase())
+}
@@ -0,0 +1,252 @@
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	ethchannel "perun.network/go-perun/backend/ethereum/channel"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/client"
+)
+
+// Backoff configures the delays between the attempts of an on-chain step.
+type Backoff struct {
+	// Initial is the delay after the first failed attempt.
+	Initial time.Duration
+	// Max is the maximum delay between two attempts.
+	Max time.Duration
+	// Factor is the factor by which the delay grows with each attempt.
+	Factor float64
+	// Attempts is the maximum number of attempts. Zero means that the step is
+	// retried until the context is done.
+	Attempts int
+}
+
+// DefaultBackoff is the backoff of new connections.
+var DefaultBackoff = Backoff{
+	Initial:  time.Second,
+	Max:      time.Minute,
+	Factor:   2,
+	Attempts: 8,
+}
+
+// delay returns the delay after the given failed attempt.
+func (b Backoff) delay(attempt int) time.Duration {
+	d := float64(b.Initial) * math.Pow(b.Factor, float64(attempt-1))
+	if d > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(d)
+}
+
+// DisputeStep is a step of the on-chain dispute resolution.
+type DisputeStep string
+
+const (
+	// StepProgress registers the channel state, if necessary, and
+	// progresses it with an update.
+	StepProgress DisputeStep = "progress"
+	// StepAwaitConcludable waits until the timeout of the dispute elapsed.
+	StepAwaitConcludable DisputeStep = "await_concludable"
+	// StepSettle concludes the channel, registering it first if necessary,
+	// and withdraws our funds.
+	StepSettle DisputeStep = "settle"
+)
+
+// Failure classifies why an on-chain step failed.
+type Failure string
+
+const (
+	// FailureOther is any failure not covered below.
+	FailureOther Failure = "other"
+	// FailureTxTimedOut indicates that the transaction was not mined in time
+	// or was underpriced. A retry sends a new transaction with a fresh gas
+	// price estimate.
+	FailureTxTimedOut Failure = "tx_timed_out"
+	// FailureUnreachable indicates that the chain could not be reached.
+	FailureUnreachable Failure = "chain_unreachable"
+	// FailureInsufficientFunds indicates that the account cannot pay for the
+	// gas of the transaction.
+	FailureInsufficientFunds Failure = "insufficient_funds"
+	// FailureReverted indicates that the transaction was reverted, e.g.,
+	// because the dispute timeout did not elapse yet.
+	FailureReverted Failure = "reverted"
+)
+
+// classify returns the failure class of the given error.
+func classify(err error) Failure {
+	var (
+		timedOut    client.TxTimedoutError
+		unreachable client.ChainNotReachableError
+	)
+	switch {
+	case errors.As(err, &timedOut),
+		errors.Is(err, core.ErrUnderpriced),
+		errors.Is(err, core.ErrReplaceUnderpriced):
+		return FailureTxTimedOut
+	case errors.As(err, &unreachable):
+		return FailureUnreachable
+	case errors.Is(err, core.ErrInsufficientFunds):
+		return FailureInsufficientFunds
+	case errors.Is(err, ethchannel.ErrTxFailed):
+		return FailureReverted
+	default:
+		return FailureOther
+	}
+}
+
+// errProgressPending is returned by the progress step if the channel was
+// progressed locally but the progression was not observed on-chain yet.
+var errProgressPending = errors.New("progression pending on-chain")
+
+// DisputeManager drives the on-chain dispute resolution of a connection. It
+// retries failed steps with exponential backoff and reports each attempt as
+// a DisputeStepped event.
+type DisputeManager struct {
+	conn *Connection
+
+	mu      sync.Mutex
+	backoff Backoff
+}
+
+func newDisputeManager(conn *Connection) *DisputeManager {
+	return &DisputeManager{conn: conn, backoff: DefaultBackoff}
+}
+
+// SetBackoff sets the backoff of subsequent steps.
+func (m *DisputeManager) SetBackoff(b Backoff) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.backoff = b
+}
+
+// Backoff returns the backoff of subsequent steps.
+func (m *DisputeManager) Backoff() Backoff {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.backoff
+}
+
+// ForceUpdate enforces the update on-chain. The channel state is registered
+// first if it is not registered yet.
+func (m *DisputeManager) ForceUpdate(ctx context.Context, up func(*channel.State)) error {
+	c := m.conn
+	version := c.State().Version + 1
+	c.disputed.SetValue(true)
+	return m.run(ctx, StepProgress, m.Backoff(), func(ctx context.Context) error {
+		switch {
+		case c.dispute.Version() >= version:
+			// A previous attempt succeeded on-chain.
+			return nil
+		case c.State().Version >= version:
+			// The progression was sent, but may not be mined yet. It cannot
+			// be resent, so we wait for it to show up.
+			return errProgressPending
+		}
+		return c.ForceUpdate(ctx, up)
+	})
+}
+
+// Settle waits until the channel is concludable if it is disputed, and then
+// concludes the channel and withdraws our funds.
+func (m *DisputeManager) Settle(ctx context.Context) error {
+	return m.settle(ctx, m.Backoff())
+}
+
+func (m *DisputeManager) settle(ctx context.Context, b Backoff) error {
+	c := m.conn
+	if c.Disputed() {
+		err := m.run(ctx, StepAwaitConcludable, Backoff{Attempts: 1}, c.WaitConcludadable)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := m.run(ctx, StepSettle, b, func(ctx context.Context) error {
+		if c.Phase() == channel.Withdrawn {
+			// A previous attempt succeeded.
+			return nil
+		}
+		return c.Settle(ctx, false)
+	})
+	c.recordSettlement(err)
+	return err
+}
+
+// Resume resumes the settlement of a restored channel that was disputed or
+// being settled when the client stopped. It returns whether a settlement was
+// resumed. The settlement runs in the background until it is done or the
+// connection stops watching.
+func (m *DisputeManager) Resume() bool {
+	c := m.conn
+	switch c.Phase() {
+	case channel.Registering, channel.Registered, channel.Progressing, channel.Progressed, channel.Withdrawing:
+		c.disputed.SetValue(true)
+	case channel.Final:
+	default:
+		return false
+	}
+
+	go func() {
+		err := c.Close(c.dispute.ctx)
+		if err != nil {
+			c.Log().Warnf("Resuming settlement: %v", err)
+		}
+	}()
+	return true
+}
+
+// run runs the given step until it succeeds, the attempts of the backoff are
+// exhausted or the context is done.
+func (m *DisputeManager) run(ctx context.Context, step DisputeStep, b Backoff, fn func(context.Context) error) error {
+	c := m.conn
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		e := &DisputeStepped{ChannelEvent: c.channelEvent(), Step: step, Attempt: attempt}
+		if err == nil {
+			c.events.Emit(e)
+			return nil
+		}
+
+		e.Failure, e.Err = classify(err), err.Error()
+		if ctx.Err() != nil || (b.Attempts > 0 && attempt >= b.Attempts) {
+			c.events.Emit(e)
+			return fmt.Errorf("%s failed after %d attempts: %w", step, attempt, err)
+		}
+
+		delay := m.retryDelay(b, attempt, e.Failure)
+		e.RetryAt = time.Now().Add(delay)
+		c.events.Emit(e)
+		c.Log().Warnf("Step %s failed (attempt %d, %s), retrying in %v: %v", step, attempt, e.Failure, delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("%s failed after %d attempts: %w", step, attempt, err)
+		}
+	}
+}
+
+// retryDelay returns the delay before retrying a step that failed with the
+// given failure. Failures that cost gas or cannot be fixed by retrying soon
+// are delayed longer than the backoff suggests.
+func (m *DisputeManager) retryDelay(b Backoff, attempt int, f Failure) time.Duration {
+	d := b.delay(attempt)
+	switch f {
+	case FailureInsufficientFunds:
+		// Nothing changes until the account is topped up.
+		return b.Max
+	case FailureReverted:
+		// Reverted transactions cost gas. If the dispute timeout did not
+		// elapse yet, there is no point in retrying before it did.
+		if until := time.Until(m.conn.dispute.Timeout()); until > d {
+			return until
+		}
+	}
+	return d
+}
@@ -0,0 +1,54 @@
+package connection
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	ethchannel "perun.network/go-perun/backend/ethereum/channel"
+	"perun.network/go-perun/client"
+)
+
+func TestBackoff_Delay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second, Factor: 2}
+	require.Equal(t, time.Second, b.delay(1))
+	require.Equal(t, 2*time.Second, b.delay(2))
+	require.Equal(t, 4*time.Second, b.delay(3))
+	require.Equal(t, 5*time.Second, b.delay(4))
+	require.Equal(t, 5*time.Second, b.delay(100))
+}
+
+func TestClassify(t *testing.T) {
+	wrap := func(err error) error { return fmt.Errorf("forcing update: %w", err) }
+	tests := []struct {
+		err  error
+		want Failure
+	}{
+		{wrap(client.NewTxTimedoutError("progress", "0x01", "timeout")), FailureTxTimedOut},
+		{wrap(core.ErrReplaceUnderpriced), FailureTxTimedOut},
+		{wrap(errors.WithMessage(core.ErrUnderpriced, "sending transaction")), FailureTxTimedOut},
+		{wrap(client.NewChainNotReachableError(errors.New("dial"))), FailureUnreachable},
+		{wrap(errors.WithMessage(core.ErrInsufficientFunds, "sending transaction")), FailureInsufficientFunds},
+		{wrap(errors.WithMessage(ethchannel.ErrTxFailed, "concluding")), FailureReverted},
+		{wrap(errors.New("boom")), FailureOther},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, classify(tt.err), tt.err.Error())
+	}
+}
+
+func TestDisputeManager_SetBackoff(t *testing.T) {
+	m := newDisputeManager(&Connection{})
+	b := Backoff{Initial: time.Millisecond, Max: time.Second, Factor: 2, Attempts: 3}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.SetBackoff(b)
+	}()
+	m.Backoff()
+	<-done
+	require.Equal(t, b, m.Backoff())
+}
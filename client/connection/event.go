@@ -0,0 +1,252 @@
+package connection
+
+import (
+	"sync"
+	"time"
+
+	ethchannel "perun.network/go-perun/backend/ethereum/channel"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/wallet"
+)
+
+// Event is an event of the client or one of its connections. The concrete
+// event types are listed below.
+type Event interface {
+	// Kind returns the name of the event type.
+	Kind() string
+}
+
+// ChannelEvent is embedded in the events of a connection and identifies the
+// channel of the connection.
+type ChannelEvent struct {
+	ChannelID channel.ID
+}
+
+type (
+	// ConnectionProposed is emitted when a peer proposes a connection.
+	ConnectionProposed struct {
+		Peer wallet.Address
+	}
+
+	// ConnectionOpened is emitted when a connection is established.
+	ConnectionOpened struct {
+		ChannelEvent
+		Peer wallet.Address
+	}
+
+	// OfferReceived is emitted when the peer requests credentials.
+	OfferReceived struct {
+		ChannelEvent
+		OfferID uint64
+		Quoted  bool
+	}
+
+	// CredentialReceived is emitted when the peer proposes to issue the
+	// credentials of one of our requests.
+	CredentialReceived struct {
+		ChannelEvent
+		OfferID uint64
+	}
+
+	// CredentialIssued is emitted when we issued credentials. Forced is set
+	// if the update was enforced on-chain.
+	CredentialIssued struct {
+		ChannelEvent
+		OfferID uint64
+		Forced  bool
+	}
+
+	// PaymentAccepted is emitted when the peer accepts the payment for
+	// credentials that we issued.
+	PaymentAccepted struct {
+		ChannelEvent
+		OfferID uint64
+	}
+
+	// PaymentRejected is emitted when the peer rejects the payment for
+	// credentials that we issued.
+	PaymentRejected struct {
+		ChannelEvent
+		OfferID uint64
+		Reason  string
+	}
+
+	// DisputeRegistered is emitted when a state of the channel is registered
+	// on-chain.
+	DisputeRegistered struct {
+		ChannelEvent
+		Version uint64
+		Timeout time.Time
+	}
+
+	// DisputeProgressed is emitted when the registered state is progressed
+	// on-chain.
+	DisputeProgressed struct {
+		ChannelEvent
+		Version uint64
+	}
+
+	// DisputeStepped is emitted after each attempt of a step of the on-chain
+	// dispute resolution. Err is empty if the attempt succeeded, and RetryAt
+	// is the time of the next attempt if there is one.
+	DisputeStepped struct {
+		ChannelEvent
+		Step    DisputeStep
+		Attempt int
+		Failure Failure
+		Err     string
+		RetryAt time.Time
+	}
+
+	// Concluded is emitted when the channel is concluded on-chain.
+	Concluded struct {
+		ChannelEvent
+		Version uint64
+	}
+
+	// Settled is emitted when the channel is settled and our funds are
+	// withdrawn.
+	Settled struct {
+		ChannelEvent
+	}
+)
+
+func (ConnectionProposed) Kind() string { return "connection_proposed" }
+func (ConnectionOpened) Kind() string   { return "connection_opened" }
+func (OfferReceived) Kind() string      { return "offer_received" }
+func (CredentialReceived) Kind() string { return "credential_received" }
+func (CredentialIssued) Kind() string   { return "credential_issued" }
+func (PaymentAccepted) Kind() string    { return "payment_accepted" }
+func (PaymentRejected) Kind() string    { return "payment_rejected" }
+func (DisputeRegistered) Kind() string  { return "dispute_registered" }
+func (DisputeProgressed) Kind() string  { return "dispute_progressed" }
+func (DisputeStepped) Kind() string     { return "dispute_stepped" }
+func (Concluded) Kind() string          { return "concluded" }
+func (Settled) Kind() string            { return "settled" }
+
+// timeoutTime returns the time at which the given timeout elapses, or the
+// zero time if it is unknown.
+func timeoutTime(t channel.Timeout) time.Time {
+	switch t := t.(type) {
+	case *ethchannel.BlockTimeout:
+		return time.Unix(int64(t.Time), 0)
+	case *channel.TimeTimeout:
+		return t.Time
+	default:
+		return time.Time{}
+	}
+}
+
+// Feed distributes events to its subscribers. A nil feed drops all events.
+type Feed struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// NewFeed returns a feed without subscribers.
+func NewFeed() *Feed {
+	return &Feed{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription that receives all subsequent events.
+func (f *Feed) Subscribe() *Subscription {
+	s := &Subscription{
+		feed: f,
+		c:    make(chan Event),
+		done: make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	go s.deliver()
+
+	f.mu.Lock()
+	f.subs[s] = struct{}{}
+	f.mu.Unlock()
+	return s
+}
+
+// Emit sends the event to all subscribers. It does not block.
+func (f *Feed) Emit(e Event) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for s := range f.subs {
+		s.push(e)
+	}
+}
+
+func (f *Feed) remove(s *Subscription) {
+	f.mu.Lock()
+	delete(f.subs, s)
+	f.mu.Unlock()
+}
+
+// Subscription receives the events of a feed. Each subscription buffers its
+// events, so that slow subscribers do not block the connections or other
+// subscribers. A subscription must be closed when it is no longer used.
+type Subscription struct {
+	feed *Feed
+	c    chan Event
+	done chan struct{}
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []Event
+	closed bool
+}
+
+// Events returns the channel on which the events are delivered. It is closed
+// when the subscription is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.c
+}
+
+// Close ends the subscription and drops the buffered events.
+func (s *Subscription) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.queue = nil
+	close(s.done)
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	s.feed.remove(s)
+}
+
+func (s *Subscription) push(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.queue = append(s.queue, e)
+	s.cond.Signal()
+}
+
+func (s *Subscription) deliver() {
+	defer close(s.c)
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return
+		}
+		e := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		select {
+		case s.c <- e:
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package connection_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeed(t *testing.T) {
+	f := connection.NewFeed()
+	slow, fast := f.Subscribe(), f.Subscribe()
+	defer slow.Close()
+
+	// Emitting does not block on subscribers that do not read.
+	const n = 100
+	for i := 0; i < n; i++ {
+		f.Emit(&connection.OfferReceived{OfferID: uint64(i)})
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case e := <-fast.Events():
+			require.Equal(t, uint64(i), e.(*connection.OfferReceived).OfferID)
+		case <-time.After(time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	// The slow subscriber still receives all events in order.
+	e := <-slow.Events()
+	require.Equal(t, "offer_received", e.Kind())
+	require.Equal(t, uint64(0), e.(*connection.OfferReceived).OfferID)
+
+	// Closing a subscription closes its channel.
+	fast.Close()
+	_, ok := <-fast.Events()
+	require.False(t, ok)
+	f.Emit(&connection.Settled{})
+
+	// A nil feed drops events.
+	var nilFeed *connection.Feed
+	nilFeed.Emit(&connection.Settled{})
+}
@@ -3,43 +3,89 @@ package connection
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app"
 	"github.com/perun-network/perun-credential-payment/app/data"
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/client"
 )
 
 func (conn *Connection) HandleUpdate(cur *channel.State, update client.ChannelUpdate, responder *client.UpdateResponder) {
-	switch nextData := update.State.Data.(type) {
-	case *data.Offer:
-		conn.handleOffer(nextData, responder)
-
-	case *data.Cert:
-		curData := cur.Data.(*data.Offer)
-		conn.handleCert(curData, nextData, responder)
-
-	case *data.DefaultData:
-		// Always accept update. The app logic ensures that the balances do not
-		// change.
-		err := responder.Accept(context.TODO())
-		if err != nil {
-			conn.Log().Warnf("Error accepting update: %v", err)
-			return
+	next := update.State
+	if cert, ok := next.Data.(*data.Cert); ok {
+		conn.handleCert(cur, cert, responder)
+		return
+	}
+
+	// Determine the offers added by the update.
+	curOffers, nextOffers := data.PendingOffers(cur.Data), data.PendingOffers(next.Data)
+	var added []data.Offer
+	for _, o := range nextOffers {
+		if _, ok := data.FindOffer(curOffers, o.ID); !ok {
+			added = append(added, o)
 		}
+	}
 
+	switch len(added) {
+	case 0:
+	case 1:
+		// The offer is quoted if it accepts the current quote.
+		quote, ok := cur.Data.(*data.Quote)
+		quoted := ok && quote.Offer.Equal(&added[0])
+		conn.handleOffer(&added[0], quoted, responder)
+		return
 	default:
-		conn.Log().Warnf("Unexpected data type: %T", nextData)
+		err := responder.Reject(context.TODO(), "too many offers")
+		if err != nil {
+			conn.Log().Warnf("Error rejecting update: %v", err)
+		}
+		return
+	}
+
+	// Always accept update. The app logic ensures that the balances do not
+	// change, that quotes are signed by the issuer and that the buyer does not
+	// advance the version while its offers are pending.
+	err := responder.Accept(context.TODO())
+	if err != nil {
+		conn.Log().Warnf("Error accepting update: %v", err)
+		return
+	}
+	if next.IsFinal {
+		conn.markFinal()
+	}
 
+	// Resolve requests whose offers were removed by the peer.
+	for _, o := range curOffers {
+		if _, ok := data.FindOffer(nextOffers, o.ID); !ok {
+			conn.sigs.Cancel(o.ID, ErrRequestCancelled)
+		}
+	}
+
+	if quote, ok := next.Data.(*data.Quote); ok {
+		conn.handleQuote(cur, quote)
 	}
 }
 
-func (conn *Connection) handleOffer(offer *data.Offer, responder *client.UpdateResponder) {
+func (conn *Connection) handleOffer(offer *data.Offer, quoted bool, responder *client.UpdateResponder) {
+	// Typed credentials can only be issued for the chain of the channel.
+	if offer.Scheme == app.SchemeEIP712 && offer.ChainID.Cmp(conn.chainID) != 0 {
+		err := responder.Reject(context.TODO(), "wrong chain ID")
+		if err != nil {
+			conn.Log().Warnf("Error rejecting update: %v", err)
+		}
+		return
+	}
+
+	conn.events.Emit(&OfferReceived{conn.channelEvent(), offer.ID, quoted})
+
 	// Forward the request and get response.
-	response := conn.addCredentialRequest(offer)
+	response := conn.addCredentialRequest(offer, quoted)
 	r := <-response
 
 	// Send response.
-	switch r.(type) {
+	switch r := r.(type) {
 	case *CredentialRequestResponseAccept:
 		err := responder.Accept(r.Context())
 		if err != nil {
@@ -49,14 +95,73 @@ func (conn *Connection) handleOffer(offer *data.Offer, responder *client.UpdateR
 
 		r.Result() <- nil
 
+	case *CredentialRequestResponseReject:
+		err := responder.Reject(r.Context(), r.reason)
+		if err != nil {
+			r.Result() <- fmt.Errorf("rejecting update: %w", err)
+			return
+		}
+
+		r.Result() <- nil
+
 	default:
 		panic(fmt.Sprintf("unsupported type: %T", r))
 	}
 }
 
-func (conn *Connection) handleCert(curData *data.Offer, nextData *data.Cert, responder *client.UpdateResponder) {
-	// The app logic ensures that the signature is valid.
-	conn.addSignature(nextData.Signature[:], curData.DataHash, curData.Issuer, responder)
+func (conn *Connection) handleQuote(cur *channel.State, quote *data.Quote) {
+	// Only forward quotes that are new.
+	if curQuote, ok := cur.Data.(*data.Quote); ok && curQuote.Offer.Equal(&quote.Offer) {
+		return
+	}
+
+	// Only the current quote can be accepted, so a quote that was not yet
+	// received is replaced. The update handler must not block on the receiver.
+	select {
+	case <-conn.quotes:
+	default:
+	}
+	select {
+	case conn.quotes <- &Quote{offer: quote.Offer.Clone()}:
+	default:
+		conn.Log().Warnf("Dropping quote for offer %d", quote.Offer.ID)
+	}
+}
+
+func (conn *Connection) handleCert(cur *channel.State, cert *data.Cert, responder *client.UpdateResponder) {
+	offers := data.PendingOffers(cur.Data)
+	i, ok := data.FindOffer(offers, cert.ID)
+	if !ok {
+		conn.Log().Warnf("Certificate for unknown offer: %d", cert.ID)
+		if err := responder.Reject(context.TODO(), "unknown offer"); err != nil {
+			conn.Log().Warnf("Error rejecting update: %v", err)
+		}
+		return
+	}
+	offer := offers[i]
+
+	// The app logic ensures that the signatures are valid.
+	sigs := make([][]app.Signature, len(offer.DataHashes))
+	for i := range sigs {
+		sigs[i] = make([]app.Signature, len(cert.Signers))
+		for j := range cert.Signers {
+			sig := cert.Signature(i, j)
+			sigs[i][j] = sig[:]
+		}
+	}
+	signers := make([]common.Address, len(cert.Signers))
+	for j, idx := range cert.Signers {
+		signers[j] = offer.Issuers[idx]
+	}
+	conn.events.Emit(&CredentialReceived{conn.channelEvent(), cert.ID})
+	conn.sigs.Push(cert.ID, &CredentialsProposal{
+		UpdateResponder: responder,
+		Signatures:      sigs,
+		Signers:         signers,
+		Delegations:     cert.Delegations,
+		offer:           &offer,
+		binding:         conn.binding(&offer),
+	})
 }
 
 type EventHandler struct {
@@ -70,17 +175,16 @@ func NewEventHandler(conn *Connection) *EventHandler {
 func (h *EventHandler) HandleAdjudicatorEvent(e channel.AdjudicatorEvent) {
 	switch e := e.(type) {
 	case *channel.RegisteredEvent:
-		h.disputed.SetValue(true)
+		// The registered state of an app channel can be progressed for
+		// another challenge duration after the timeout.
+		progression := time.Duration(h.Params().ChallengeDuration) * time.Second
+		h.dispute.register(e.Version(), e.TimeoutV, progression)
+		h.events.Emit(&DisputeRegistered{h.channelEvent(), e.Version(), timeoutTime(e.TimeoutV)})
 	case *channel.ProgressedEvent:
-		go func() {
-			err := e.TimeoutV.Wait(context.TODO())
-			if err != nil {
-				h.Log().Warnf("waiting for timeout: %v", err)
-				return
-			}
-			h.concludable.SetValue(true)
-		}()
+		h.dispute.progress(e.Version(), e.TimeoutV)
+		h.events.Emit(&DisputeProgressed{h.channelEvent(), e.Version()})
 	case *channel.ConcludedEvent:
-		h.concluded.SetValue(true)
+		h.dispute.conclude(e.Version())
+		h.events.Emit(&Concluded{h.channelEvent(), e.Version()})
 	}
 }
@@ -0,0 +1,24 @@
+package connection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"github.com/stretchr/testify/require"
+	"perun.network/go-perun/channel"
+)
+
+func TestHandleQuote(t *testing.T) {
+	conn := &Connection{quotes: make(chan *Quote, 1)}
+	cur := &channel.State{Data: &data.DefaultData{}}
+
+	// Quotes that are not received do not block the handler and are replaced
+	// by newer quotes.
+	conn.handleQuote(cur, &data.Quote{Offer: data.Offer{ID: 1}})
+	conn.handleQuote(cur, &data.Quote{Offer: data.Offer{ID: 2}})
+
+	q, err := conn.NextQuote(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), q.offer.ID)
+}
@@ -0,0 +1,91 @@
+package connection
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/channel"
+)
+
+// Issuance describes the outcome of an attempt to issue the credentials of a
+// request. Forced is set if the credentials were issued by forcing the update
+// on-chain. Err is nil if the credentials were issued.
+type Issuance struct {
+	Time      time.Time
+	ChannelID channel.ID
+	OfferID   uint64
+	Holder    common.Address
+	Asset     common.Address
+	DocHashes []app.Hash
+	Prices    []*big.Int
+	Forced    bool
+	Err       error
+}
+
+// ChannelSettlement describes the outcome of settling a channel. Err is nil if
+// the channel was concluded and our funds were withdrawn.
+type ChannelSettlement struct {
+	Time      time.Time
+	ChannelID channel.ID
+	Err       error
+}
+
+// IssuanceLog records the outcomes of credential issuance and of the
+// settlement of the channels in which credentials were issued.
+type IssuanceLog interface {
+	RecordIssuance(*Issuance) error
+	RecordSettlement(*ChannelSettlement) error
+}
+
+// SetIssuanceLog sets the log that records the outcome of every subsequent
+// attempt to issue credentials or to settle the connection.
+func (c *Connection) SetIssuanceLog(l IssuanceLog) {
+	c.issuanceLog = l
+}
+
+// recordIssuance records the outcome of issuing the credentials for the given
+// offer, if an issuance log is set. Forced is set if the update was enforced
+// on-chain.
+func (c *Connection) recordIssuance(offer *data.Offer, forced bool, err error) {
+	if c.issuanceLog == nil {
+		return
+	}
+
+	params := c.Params()
+	is := &Issuance{
+		Time:      time.Now(),
+		ChannelID: c.ID(),
+		OfferID:   offer.ID,
+		Holder:    ethwallet.AsEthAddr(params.Parts[offer.Buyer]),
+		DocHashes: append([]app.Hash(nil), offer.DataHashes...),
+		Prices:    make([]*big.Int, len(offer.Prices)),
+		Forced:    forced,
+		Err:       err,
+	}
+	for i, p := range offer.Prices {
+		is.Prices[i] = new(big.Int).Set(p)
+	}
+	if a, ok := c.State().Assets[offer.Asset].(*ethwallet.Address); ok {
+		is.Asset = ethwallet.AsEthAddr(a)
+	}
+	if lerr := c.issuanceLog.RecordIssuance(is); lerr != nil {
+		c.Log().Warnf("Recording issuance: %v", lerr)
+	}
+}
+
+// recordSettlement records the outcome of settling the channel, if an
+// issuance log is set.
+func (c *Connection) recordSettlement(err error) {
+	if c.issuanceLog == nil {
+		return
+	}
+
+	s := &ChannelSettlement{Time: time.Now(), ChannelID: c.ID(), Err: err}
+	if lerr := c.issuanceLog.RecordSettlement(s); lerr != nil {
+		c.Log().Warnf("Recording settlement: %v", lerr)
+	}
+}
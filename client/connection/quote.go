@@ -0,0 +1,160 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/channel"
+)
+
+// Quote is an offer for credentials that was proposed by the issuer.
+type Quote struct {
+	offer *data.Offer
+}
+
+// Issuer returns the address of the issuer.
+func (q *Quote) Issuer() common.Address {
+	return q.offer.Issuers[0]
+}
+
+// DataHashes returns the hashes of the quoted documents.
+func (q *Quote) DataHashes() []app.Hash {
+	return append([]app.Hash(nil), q.offer.DataHashes...)
+}
+
+// Prices returns the prices of the quoted documents.
+func (q *Quote) Prices() []*big.Int {
+	prices := make([]*big.Int, len(q.offer.Prices))
+	for i, p := range q.offer.Prices {
+		prices[i] = new(big.Int).Set(p)
+	}
+	return prices
+}
+
+// TotalPrice returns the sum of the prices of the quoted documents.
+func (q *Quote) TotalPrice() *big.Int {
+	return q.offer.TotalPrice()
+}
+
+// Expiry returns the channel version until which the issuer can issue the
+// credentials once the quote is accepted.
+func (q *Quote) Expiry() uint64 {
+	return q.offer.Expiry
+}
+
+// SendQuote proposes to sell the credentials for the given documents to the
+// peer at the given prices. The quote is signed with the given signer, which
+// is the issuer of the credentials. The price is paid in the asset held by the
+// given asset holder.
+func (c *Connection) SendQuote(
+	ctx context.Context,
+	docs [][]byte,
+	asset common.Address,
+	prices []channel.Bal,
+	signer app.Signer,
+) error {
+	return c.SendSaltedQuote(ctx, docs, nil, asset, prices, signer)
+}
+
+// SendSaltedQuote is like SendQuote, but the quote commits to the i-th
+// document with the i-th salt instead of containing the plain document hash.
+func (c *Connection) SendSaltedQuote(
+	ctx context.Context,
+	docs [][]byte,
+	salts [][]byte,
+	asset common.Address,
+	prices []channel.Bal,
+	signer app.Signer,
+) error {
+	if len(docs) == 0 || len(docs) != len(prices) {
+		return fmt.Errorf("invalid number of documents or prices")
+	} else if salts != nil && len(salts) != len(docs) {
+		return fmt.Errorf("invalid number of salts")
+	} else if _, ok := signer.(*app.DelegatedSigner); ok {
+		// Quotes are verified against the issuer address.
+		return fmt.Errorf("quotes cannot be signed by a delegated key")
+	}
+
+	// Compute commitments.
+	hs := make([]app.Hash, len(docs))
+	for i, doc := range docs {
+		hs[i] = app.ComputeSaltedDocumentHash(doc, saltAt(salts, i))
+	}
+
+	err := c.UpdateBy(ctx, func(s *channel.State) error {
+		assetIdx, ok := s.Allocation.AssetIndex(ethwallet.AsWalletAddr(asset))
+		if !ok {
+			return fmt.Errorf("unknown asset: %v", asset)
+		}
+
+		// The quote is created with the next version, which also serves as
+		// the ID of the quoted offer.
+		quoteVersion := s.Version + 1
+		quote := data.Quote{
+			Offer: data.Offer{
+				ID:         quoteVersion,
+				Issuers:    []common.Address{signer.Address()},
+				Threshold:  1,
+				DataHashes: hs,
+				Prices:     prices,
+				Asset:      uint16(assetIdx),
+				Buyer:      uint16(1 - c.Idx()),
+				Expiry:     quoteVersion + c.offerValidity,
+				CreatedAt:  uint64(time.Now().Unix()),
+				Scheme:     c.sigScheme,
+				ChainID:    new(big.Int).Set(c.chainID),
+			},
+		}
+
+		// Sign.
+		var err error
+		quote.Signature, err = signer.SignOffer(&quote.Offer)
+		if err != nil {
+			return fmt.Errorf("signing quote: %w", err)
+		}
+
+		quote.Pending = data.PendingOffers(s.Data)
+		s.Data = &quote
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("updating channel: %w", err)
+	}
+	return nil
+}
+
+// NextQuote returns the next quote that was proposed by the peer. Only the
+// latest quote is kept until it is received, as earlier quotes can no longer
+// be accepted.
+func (c *Connection) NextQuote(ctx context.Context) (*Quote, error) {
+	select {
+	case q := <-c.quotes:
+		return q, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// AcceptQuote accepts the given quote by requesting the quoted credentials at
+// the quoted prices. The quote must be the current channel data. If the issuer
+// rejects the request, the returned AsyncCredentials resolves to a
+// *RequestRejectedError.
+func (c *Connection) AcceptQuote(ctx context.Context, q *Quote) (*AsyncCredentials, error) {
+	return c.requestOffer(ctx, func(s *channel.State) (*data.Offer, error) {
+		cur, ok := s.Data.(*data.Quote)
+		if !ok {
+			return nil, fmt.Errorf("data has wrong type: %T", s.Data)
+		} else if !cur.Offer.Equal(q.offer) {
+			return nil, fmt.Errorf("unequal quotes: got %v, expected %v", cur.Offer, q.offer)
+		} else if channel.Index(q.offer.Buyer) != c.Idx() {
+			return nil, fmt.Errorf("quote is not addressed to us")
+		}
+		return q.offer.Clone(), nil
+	})
+}
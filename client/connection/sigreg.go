@@ -4,71 +4,74 @@ import (
 	"context"
 	"fmt"
 	"sync"
-
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/perun-network/perun-credential-payment/app"
-	"perun.network/go-perun/client"
 )
 
 type (
-	sigRegKey struct {
-		Issuer  common.Address
-		DocHash app.Hash
+	sigRegResult struct {
+		Proposal *CredentialsProposal
+		Err      error
 	}
 
-	sigRegReturnVal = *CredentialProposal
+	sigRegReturnVal = *sigRegResult
 
+	// sigReg holds the callbacks of the pending credential requests, keyed by
+	// offer ID.
 	sigReg struct {
 		sync.RWMutex
-		callbacks map[sigRegKey]chan sigRegReturnVal
+		callbacks map[uint64]chan sigRegReturnVal
 	}
 )
 
 func newSigReg() *sigReg {
 	return &sigReg{
-		callbacks: make(map[sigRegKey]chan sigRegReturnVal),
+		callbacks: make(map[uint64]chan sigRegReturnVal),
 	}
 }
 
-func (r *sigReg) RegisterCallback(h app.Hash, issuer common.Address) (sigRegCallback, error) {
+func (r *sigReg) RegisterCallback(id uint64) (sigRegCallback, error) {
 	r.Lock()
 	defer r.Unlock()
 
-	k := sigRegKey{Issuer: issuer, DocHash: h}
 	callback := make(chan sigRegReturnVal, 1)
 
-	_, ok := r.callbacks[k]
+	_, ok := r.callbacks[id]
 	if ok {
 		return nil, fmt.Errorf("already registered")
 	}
 
-	r.callbacks[k] = callback
+	r.callbacks[id] = callback
 	return sigRegCallback(callback), nil
 }
 
-func (r *sigReg) Push(sig app.Signature, h app.Hash, issuer common.Address, responder *client.UpdateResponder) {
+func (r *sigReg) Push(id uint64, p *CredentialsProposal) {
+	r.resolve(id, &sigRegResult{Proposal: p})
+}
+
+// Cancel resolves the callback registered for the given offer with the given
+// error.
+func (r *sigReg) Cancel(id uint64, err error) {
+	r.resolve(id, &sigRegResult{Err: err})
+}
+
+func (r *sigReg) resolve(id uint64, res sigRegReturnVal) {
 	r.Lock()
 	defer r.Unlock()
 
-	k := sigRegKey{Issuer: issuer, DocHash: h}
-	cb, ok := r.callbacks[k]
+	cb, ok := r.callbacks[id]
 	if !ok {
 		return
 	}
 
-	cb <- &CredentialProposal{
-		UpdateResponder: responder,
-		Signature:       sig,
-	}
-	delete(r.callbacks, k)
+	cb <- res
+	delete(r.callbacks, id)
 }
 
 type sigRegCallback chan sigRegReturnVal
 
-func (cb sigRegCallback) Await(ctx context.Context) (sigRegReturnVal, error) {
+func (cb sigRegCallback) Await(ctx context.Context) (*CredentialsProposal, error) {
 	select {
 	case r := <-cb:
-		return r, nil
+		return r.Proposal, r.Err
 	case <-ctx.Done():
 		return nil, fmt.Errorf("waiting for callback: %w", ctx.Err())
 	}
@@ -0,0 +1,147 @@
+// Package credstore implements the holder's credential wallet. It keeps the
+// received credentials together with the details of their purchase in a
+// key-value database.
+package credstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/leveldb"
+)
+
+const recordPrefix = "credential:"
+
+// Record is a received credential together with the ID of the offer by which
+// it was bought, the price paid for it and the time at which it was received.
+type Record struct {
+	Credential *app.Credential
+	OfferID    uint64
+	Price      *big.Int
+	ReceivedAt time.Time
+}
+
+// ID returns the identifier of the record, which is derived from the channel,
+// the offer and the document commitment of the credential. Buying the same
+// document twice in a channel therefore yields two records.
+func (r *Record) ID() string {
+	return fmt.Sprintf("%x:%d:%x", r.Credential.ChannelID, r.OfferID, r.DocHash())
+}
+
+// DocHash returns the commitment to the document of the credential.
+func (r *Record) DocHash() app.Hash {
+	return app.ComputeSaltedDocumentHash(r.Credential.Document, r.Credential.Salt)
+}
+
+// Store is a holder's credential store.
+type Store struct {
+	db sortedkv.Database
+}
+
+// Open opens the store backed by the LevelDB database at the given path.
+func Open(path string) (*Store, error) {
+	db, err := leveldb.LoadDatabase(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading database: %w", err)
+	}
+	return NewStore(db), nil
+}
+
+// NewStore returns a store backed by the given database.
+func NewStore(db sortedkv.Database) *Store {
+	return &Store{db: db}
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add stores the given record. A record with the same ID is overwritten.
+func (s *Store) Add(r *Record) error {
+	b, err := json.Marshal(newJSONRecord(r))
+	if err != nil {
+		return fmt.Errorf("encoding record: %w", err)
+	}
+	return s.db.PutBytes(recordPrefix+r.ID(), b)
+}
+
+// Get returns the record with the given ID.
+func (s *Store) Get(id string) (*Record, error) {
+	b, err := s.db.GetBytes(recordPrefix + id)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecord(b)
+}
+
+// Delete removes the record with the given ID.
+func (s *Store) Delete(id string) error {
+	return s.db.Delete(recordPrefix + id)
+}
+
+// All returns all records in the order of their IDs.
+func (s *Store) All() ([]*Record, error) {
+	return s.filter(func(*Record) bool { return true })
+}
+
+// ByIssuer returns the records of the credentials signed by the given issuer.
+func (s *Store) ByIssuer(issuer common.Address) ([]*Record, error) {
+	return s.filter(func(r *Record) bool {
+		for _, a := range r.Credential.Issuers {
+			if a == issuer {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ByDocHash returns the records of the credentials with the given document
+// commitment.
+func (s *Store) ByDocHash(h app.Hash) ([]*Record, error) {
+	return s.filter(func(r *Record) bool { return r.DocHash() == h })
+}
+
+// ReceivedBetween returns the records of the credentials received in the
+// interval [from, to).
+func (s *Store) ReceivedBetween(from, to time.Time) ([]*Record, error) {
+	return s.filter(func(r *Record) bool {
+		return !r.ReceivedAt.Before(from) && r.ReceivedAt.Before(to)
+	})
+}
+
+// Export returns the presentation of the credential with the given ID, see
+// MarshalPresentation.
+func (s *Store) Export(id string) ([]byte, error) {
+	r, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalPresentation(r.Credential)
+}
+
+func (s *Store) filter(match func(*Record) bool) (records []*Record, err error) {
+	it := s.db.NewIteratorWithPrefix(recordPrefix)
+	defer func() {
+		if cerr := it.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	for it.Next() {
+		r, err := decodeRecord(it.ValueBytes())
+		if err != nil {
+			return nil, fmt.Errorf("decoding record %s: %w", it.Key(), err)
+		}
+		if match(r) {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
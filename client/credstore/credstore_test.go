@@ -0,0 +1,87 @@
+package credstore_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"github.com/perun-network/perun-credential-payment/client/credstore"
+	"github.com/stretchr/testify/require"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+)
+
+func record(doc string, issuer common.Address, receivedAt time.Time) *credstore.Record {
+	return &credstore.Record{
+		Credential: &app.Credential{
+			Document:    []byte(doc),
+			Salt:        []byte{1, 2, 3},
+			Signatures:  []app.Signature{{4, 5, 6}},
+			Issuers:     []common.Address{issuer},
+			Delegations: []data.Delegation{{Delegate: common.Address{9}, NotBefore: 5, NotAfter: 10, Signature: [data.SigLen]byte{7}}},
+			Scheme:      app.SchemeEIP712,
+			ChainID:     big.NewInt(1337),
+			CreatedAt:   7,
+			Binding: app.Binding{
+				ChannelID: [32]byte{8},
+				Holder:    common.Address{2},
+				Contract:  common.Address{3},
+			},
+		},
+		OfferID:    1,
+		Price:      big.NewInt(100),
+		ReceivedAt: receivedAt.UTC(),
+	}
+}
+
+func TestStore(t *testing.T) {
+	require := require.New(t)
+	s := credstore.NewStore(memorydb.NewDatabase())
+
+	now := time.Unix(1_700_000_000, 0)
+	r1 := record("doc 1", common.Address{1}, now)
+	r2 := record("doc 2", common.Address{2}, now.Add(time.Hour))
+	require.NoError(s.Add(r1))
+	require.NoError(s.Add(r2))
+
+	r, err := s.Get(r1.ID())
+	require.NoError(err)
+	require.Equal(r1, r)
+
+	all, err := s.All()
+	require.NoError(err)
+	require.Len(all, 2)
+
+	rs, err := s.ByIssuer(common.Address{2})
+	require.NoError(err)
+	require.Equal([]*credstore.Record{r2}, rs)
+
+	rs, err = s.ByDocHash(r1.DocHash())
+	require.NoError(err)
+	require.Equal([]*credstore.Record{r1}, rs)
+
+	rs, err = s.ReceivedBetween(now.Add(time.Minute), now.Add(2*time.Hour))
+	require.NoError(err)
+	require.Equal([]*credstore.Record{r2}, rs)
+
+	b, err := s.Export(r1.ID())
+	require.NoError(err)
+	cred, err := credstore.UnmarshalPresentation(b)
+	require.NoError(err)
+	require.Equal(r1.Credential, cred)
+
+	// Buying the same document again in the channel does not overwrite the
+	// first record.
+	again := record("doc 1", common.Address{1}, now.Add(2*time.Hour))
+	again.OfferID = 2
+	require.NoError(s.Add(again))
+	rs, err = s.ByDocHash(r1.DocHash())
+	require.NoError(err)
+	require.Equal([]*credstore.Record{r1, again}, rs)
+
+	require.NoError(s.Delete(r1.ID()))
+	_, err = s.Get(r1.ID())
+	require.Error(err)
+}
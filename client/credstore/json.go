@@ -0,0 +1,144 @@
+package credstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+)
+
+type (
+	jsonDelegation struct {
+		Delegate  common.Address `json:"delegate"`
+		NotBefore uint64         `json:"notBefore"`
+		NotAfter  uint64         `json:"notAfter"`
+		Signature hexutil.Bytes  `json:"signature"`
+	}
+
+	// jsonCredential is the presentation format of a credential.
+	jsonCredential struct {
+		Document    hexutil.Bytes    `json:"document"`
+		Salt        hexutil.Bytes    `json:"salt,omitempty"`
+		Signatures  []hexutil.Bytes  `json:"signatures"`
+		Issuers     []common.Address `json:"issuers"`
+		Delegations []jsonDelegation `json:"delegations,omitempty"`
+		Scheme      app.SigScheme    `json:"scheme"`
+		ChainID     *hexutil.Big     `json:"chainId,omitempty"`
+		CreatedAt   uint64           `json:"createdAt,omitempty"`
+		ChannelID   common.Hash      `json:"channelId"`
+		Holder      common.Address   `json:"holder"`
+		Contract    common.Address   `json:"contract"`
+	}
+
+	jsonRecord struct {
+		Credential *jsonCredential `json:"credential"`
+		OfferID    uint64          `json:"offerId"`
+		Price      *hexutil.Big    `json:"price"`
+		ReceivedAt time.Time       `json:"receivedAt"`
+	}
+)
+
+// MarshalPresentation encodes the credential as JSON so that it can be
+// presented to a verifier, who decodes it with UnmarshalPresentation.
+func MarshalPresentation(c *app.Credential) ([]byte, error) {
+	return json.MarshalIndent(newJSONCredential(c), "", "  ")
+}
+
+// UnmarshalPresentation decodes a credential encoded by MarshalPresentation.
+func UnmarshalPresentation(b []byte) (*app.Credential, error) {
+	var c jsonCredential
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return c.credential()
+}
+
+func newJSONCredential(c *app.Credential) *jsonCredential {
+	jc := &jsonCredential{
+		Document:  c.Document,
+		Salt:      c.Salt,
+		Issuers:   c.Issuers,
+		Scheme:    c.Scheme,
+		CreatedAt: c.CreatedAt,
+		ChannelID: common.Hash(c.ChannelID),
+		Holder:    c.Holder,
+		Contract:  c.Contract,
+	}
+	for _, sig := range c.Signatures {
+		jc.Signatures = append(jc.Signatures, hexutil.Bytes(sig))
+	}
+	for _, d := range c.Delegations {
+		jc.Delegations = append(jc.Delegations, jsonDelegation{
+			Delegate:  d.Delegate,
+			NotBefore: d.NotBefore,
+			NotAfter:  d.NotAfter,
+			Signature: d.Signature[:],
+		})
+	}
+	if c.ChainID != nil {
+		jc.ChainID = (*hexutil.Big)(c.ChainID)
+	}
+	return jc
+}
+
+func (jc *jsonCredential) credential() (*app.Credential, error) {
+	c := &app.Credential{
+		Document:  jc.Document,
+		Salt:      jc.Salt,
+		Issuers:   jc.Issuers,
+		Scheme:    jc.Scheme,
+		CreatedAt: jc.CreatedAt,
+		Binding: app.Binding{
+			ChannelID: jc.ChannelID,
+			Holder:    jc.Holder,
+			Contract:  jc.Contract,
+		},
+	}
+	for _, sig := range jc.Signatures {
+		c.Signatures = append(c.Signatures, app.Signature(sig))
+	}
+	for _, d := range jc.Delegations {
+		if len(d.Signature) != data.SigLen {
+			return nil, fmt.Errorf("invalid delegation signature length")
+		}
+		del := data.Delegation{Delegate: d.Delegate, NotBefore: d.NotBefore, NotAfter: d.NotAfter}
+		copy(del.Signature[:], d.Signature)
+		c.Delegations = append(c.Delegations, del)
+	}
+	if jc.ChainID != nil {
+		c.ChainID = jc.ChainID.ToInt()
+	}
+	return c, nil
+}
+
+func newJSONRecord(r *Record) *jsonRecord {
+	return &jsonRecord{
+		Credential: newJSONCredential(r.Credential),
+		OfferID:    r.OfferID,
+		Price:      (*hexutil.Big)(r.Price),
+		ReceivedAt: r.ReceivedAt,
+	}
+}
+
+func decodeRecord(b []byte) (*Record, error) {
+	var jr jsonRecord
+	if err := json.Unmarshal(b, &jr); err != nil {
+		return nil, err
+	} else if jr.Credential == nil {
+		return nil, fmt.Errorf("missing credential")
+	}
+	c, err := jr.Credential.credential()
+	if err != nil {
+		return nil, err
+	}
+	r := &Record{Credential: c, OfferID: jr.OfferID, ReceivedAt: jr.ReceivedAt}
+	if jr.Price != nil {
+		r.Price = new(big.Int).Set(jr.Price.ToInt())
+	}
+	return r, nil
+}
@@ -0,0 +1,516 @@
+// Package daemon exposes a client over a local HTTP/JSON API, so that agents
+// written in other languages can use it.
+//
+// The API has the following endpoints:
+//
+//	GET  /balances                                    on-chain and channel balances
+//	GET  /connections                                 list the connections
+//	POST /connections                                 connect to a peer: {"peer", "deposit", "token"}
+//	POST /connections/{id}/close                      close a connection and withdraw the funds
+//	POST /connections/{id}/requests                   request a credential: {"document", "price", "token"}
+//	GET  /requests                                    list the credential requests
+//	POST /connections/{id}/requests/{offer}/issue     issue the credential of an incoming request
+//	POST /connections/{id}/requests/{offer}/accept    pay for the credential of an outgoing request: {"document"}
+//	POST /connections/{id}/requests/{offer}/reject    reject an incoming request or a proposed credential: {"reason"}
+//	GET  /events                                      stream of server-sent events
+//
+// Every request must carry the token of the server as a bearer token in its
+// Authorization header, and POST requests must have the Content-Type
+// application/json. Requests must address the server by localhost, an IP
+// address or one of the configured host names, which prevents DNS rebinding.
+//
+// Amounts and documents are hex-encoded, as in the Ethereum JSON-RPC API. An
+// empty token denotes ETH. Connections proposed by peers are accepted
+// automatically, and connections finalized by the peer are settled. Incoming
+// credential requests should be answered promptly, since the channel update
+// of the peer waits for the answer.
+//
+// Each event on the event stream has the kind of the event as its name and a
+// JSON object as its data. Besides the events of the client, the stream
+// carries request_updated events when the status of a credential request
+// changes.
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/perun-network/perun-credential-payment/client"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"github.com/perun-network/perun-credential-payment/client/credstore"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/channel"
+)
+
+// Config configures the access control of a server.
+type Config struct {
+	// Token is the bearer token that authenticates requests. It must not be
+	// empty.
+	Token string
+	// Hosts are the host names by which the server may be addressed besides
+	// localhost and IP addresses.
+	Hosts []string
+}
+
+// Server serves the API of a client. It implements http.Handler.
+type Server struct {
+	client *client.Client
+	store  *credstore.Store
+	ctx    context.Context
+	events *connection.Feed
+	token  string
+	hosts  map[string]bool
+
+	mu       sync.Mutex
+	requests map[requestKey]*request
+	order    []*request
+	serving  map[channel.ID]bool
+}
+
+// New returns a server for the given client. Received credentials are added
+// to the given store, which may be nil. The context bounds the background
+// operations of the server, such as accepting connections and awaiting
+// credentials.
+func New(ctx context.Context, c *client.Client, store *credstore.Store, cfg Config) (*Server, error) {
+	if cfg.Token == "" {
+		return nil, errors.New("empty token")
+	}
+	s := &Server{
+		client:   c,
+		store:    store,
+		ctx:      ctx,
+		events:   connection.NewFeed(),
+		token:    cfg.Token,
+		hosts:    map[string]bool{"localhost": true},
+		requests: make(map[requestKey]*request),
+		serving:  make(map[channel.ID]bool),
+	}
+	for _, h := range cfg.Hosts {
+		s.hosts[strings.ToLower(h)] = true
+	}
+	for _, conn := range c.RestoredConnections() {
+		s.serve(conn)
+		for _, req := range conn.RestoredCredentialRequests() {
+			s.addIncoming(conn, req)
+		}
+		for _, async := range conn.RestoredRequests() {
+			s.addOutgoing(conn, async, nil)
+		}
+	}
+	go s.acceptConnections()
+	return s, nil
+}
+
+func (s *Server) acceptConnections() {
+	for {
+		req, err := s.client.NextConnectionRequest(s.ctx)
+		if err != nil {
+			s.client.Logf("Awaiting connection request: %v", err)
+			return
+		}
+		conn, err := req.Accept(s.ctx)
+		if err != nil {
+			s.client.Logf("Accepting connection from %v: %v", req.Peer(), err)
+			continue
+		}
+		s.serve(conn)
+	}
+}
+
+// serve collects the incoming credential requests of the connection and
+// settles the connection once it is finalized.
+func (s *Server) serve(conn *connection.Connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.serving[conn.ID()] {
+		return
+	}
+	s.serving[conn.ID()] = true
+
+	go func() {
+		if err := conn.WaitConcludadable(s.ctx); err != nil {
+			return
+		}
+		if err := conn.Close(s.ctx); err != nil {
+			conn.Log().Warnf("Closing connection: %v", err)
+			return
+		}
+		s.mu.Lock()
+		delete(s.serving, conn.ID())
+		s.mu.Unlock()
+	}()
+	go func() {
+		for {
+			req, err := conn.NextCredentialRequest(s.ctx)
+			if err != nil {
+				return
+			}
+			s.addIncoming(conn, req)
+		}
+	}()
+}
+
+func (s *Server) addIncoming(conn *connection.Connection, req *connection.CredentialRequest) {
+	s.add(&request{conn: conn, id: req.ID(), incoming: true, events: s.events, status: StatusPending, req: req})
+}
+
+func (s *Server) addOutgoing(conn *connection.Connection, async *connection.AsyncCredentials, doc []byte) *request {
+	r := &request{conn: conn, id: async.ID(), events: s.events, status: StatusPending, doc: doc}
+	s.add(r)
+	go r.await(s.ctx, async)
+	return r
+}
+
+func (s *Server) add(r *request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.requests[r.key()]; !ok {
+		s.order = append(s.order, r)
+	}
+	s.requests[r.key()] = r
+}
+
+type route struct {
+	method  string
+	pattern []string // "*" matches any segment.
+	handle  func(s *Server, r *http.Request, path []string) (interface{}, error)
+}
+
+var routes = []route{
+	{http.MethodGet, []string{"balances"}, (*Server).balances},
+	{http.MethodGet, []string{"connections"}, (*Server).listConnections},
+	{http.MethodPost, []string{"connections"}, (*Server).connect},
+	{http.MethodPost, []string{"connections", "*", "close"}, (*Server).closeConnection},
+	{http.MethodPost, []string{"connections", "*", "requests"}, (*Server).requestCredential},
+	{http.MethodGet, []string{"requests"}, (*Server).listRequests},
+	{http.MethodPost, []string{"connections", "*", "requests", "*", "*"}, (*Server).act},
+}
+
+func (rt *route) match(path []string) bool {
+	if len(path) != len(rt.pattern) {
+		return false
+	}
+	for i, seg := range rt.pattern {
+		if seg != "*" && seg != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ServeHTTP checks the access to the server and routes the request to its
+// handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, err := s.checkAccess(r); err != nil {
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) == 1 && path[0] == "events" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		s.streamEvents(w, r)
+		return
+	}
+
+	status := http.StatusNotFound
+	for _, rt := range routes {
+		if !rt.match(path) {
+			continue
+		} else if rt.method != r.Method {
+			status = http.StatusMethodNotAllowed
+			continue
+		}
+		v, err := rt.handle(s, r, path)
+		if err != nil {
+			writeError(w, statusOf(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, v)
+		return
+	}
+	if status == http.StatusMethodNotAllowed {
+		writeError(w, status, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	writeError(w, status, fmt.Errorf("not found: %s", r.URL.Path))
+}
+
+// checkAccess checks the host, the token and the content type of the request.
+// It returns the status code with which the request is refused.
+func (s *Server) checkAccess(r *http.Request) (int, error) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if net.ParseIP(host) == nil && !s.hosts[host] {
+		return http.StatusForbidden, fmt.Errorf("invalid host: %q", r.Host)
+	}
+
+	const prefix = "Bearer "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) || subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(s.token)) != 1 {
+		return http.StatusUnauthorized, errors.New("unauthorized")
+	}
+
+	if r.Method == http.MethodPost {
+		mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mt != "application/json" {
+			return http.StatusUnsupportedMediaType, errors.New("content type must be application/json")
+		}
+	}
+	return 0, nil
+}
+
+// Errors that are mapped to HTTP status codes.
+var (
+	errNotFound   = errors.New("not found")
+	errBadRequest = errors.New("bad request")
+)
+
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, errNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, errConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, jsonError{err.Error()})
+}
+
+// decode decodes the JSON body of the request into v.
+func decode(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: decoding body: %v", errBadRequest, err)
+	}
+	return nil
+}
+
+func (s *Server) balances(*http.Request, []string) (interface{}, error) {
+	onChain, err := s.client.OnChainBalance()
+	if err != nil {
+		return nil, fmt.Errorf("reading on-chain balance: %w", err)
+	}
+	return &jsonBalances{
+		Address:     s.client.Address(),
+		OnChain:     (*hexutil.Big)(onChain),
+		Connections: s.connections(),
+	}, nil
+}
+
+func (s *Server) listConnections(*http.Request, []string) (interface{}, error) {
+	return s.connections(), nil
+}
+
+func (s *Server) connections() []jsonConnection {
+	conns := []jsonConnection{}
+	for _, conn := range s.client.Connections() {
+		conns = append(conns, newJSONConnection(conn))
+	}
+	return conns
+}
+
+func newJSONConnection(conn *connection.Connection) jsonConnection {
+	st := conn.State()
+	ours, theirs := conn.Idx(), 1-conn.Idx()
+	jc := jsonConnection{
+		ID:       common.Hash(conn.ID()),
+		Peer:     ethwallet.AsEthAddr(conn.Params().Parts[theirs]),
+		Phase:    conn.Phase().String(),
+		Version:  st.Version,
+		Disputed: conn.Disputed(),
+		Balances: []jsonBalance{},
+	}
+	for i, a := range st.Assets {
+		jc.Balances = append(jc.Balances, jsonBalance{
+			Asset: ethwallet.AsEthAddr(a.(*ethwallet.Address)),
+			Ours:  (*hexutil.Big)(st.Balances[i][ours]),
+			Peer:  (*hexutil.Big)(st.Balances[i][theirs]),
+		})
+	}
+	return jc
+}
+
+func (s *Server) connect(r *http.Request, _ []string) (interface{}, error) {
+	var req connectRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Deposit == nil {
+		return nil, fmt.Errorf("%w: missing deposit", errBadRequest)
+	}
+	ah, err := s.asset(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := s.client.Connect(r.Context(), ethwallet.AsWalletAddr(req.Peer), client.Funding{Asset: ah, Balance: req.Deposit.ToInt()})
+	if err != nil {
+		return nil, fmt.Errorf("connecting: %w", err)
+	}
+	s.serve(conn)
+	return newJSONConnection(conn), nil
+}
+
+func (s *Server) closeConnection(r *http.Request, path []string) (interface{}, error) {
+	conn, err := s.connection(path[1])
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Close(r.Context()); err != nil {
+		return nil, fmt.Errorf("closing connection: %w", err)
+	}
+	return newJSONConnection(conn), nil
+}
+
+func (s *Server) requestCredential(r *http.Request, path []string) (interface{}, error) {
+	conn, err := s.connection(path[1])
+	if err != nil {
+		return nil, err
+	}
+	var req credentialRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Document) == 0 || req.Price == nil {
+		return nil, fmt.Errorf("%w: missing document or price", errBadRequest)
+	}
+	ah, err := s.asset(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	issuer := ethwallet.AsEthAddr(conn.Params().Parts[1-conn.Idx()])
+	async, err := conn.RequestCredentials(r.Context(), [][]byte{req.Document}, ah, []channel.Bal{req.Price.ToInt()}, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("requesting credential: %w", err)
+	}
+	return s.addOutgoing(conn, async, req.Document).json(), nil
+}
+
+func (s *Server) listRequests(*http.Request, []string) (interface{}, error) {
+	s.mu.Lock()
+	order := append([]*request(nil), s.order...)
+	s.mu.Unlock()
+	reqs := []*jsonRequest{}
+	for _, r := range order {
+		reqs = append(reqs, r.json())
+	}
+	return reqs, nil
+}
+
+// act performs the action given by the last path segment on a credential
+// request.
+func (s *Server) act(r *http.Request, path []string) (interface{}, error) {
+	conn, err := s.connection(path[1])
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(path[3], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid request ID: %q", errBadRequest, path[3])
+	}
+	s.mu.Lock()
+	req, ok := s.requests[requestKey{conn.ID(), id}]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: request %d", errNotFound, id)
+	}
+
+	ctx := r.Context()
+	switch action := path[4]; {
+	case action == "issue" && req.incoming:
+		err = req.issue(ctx, s.client.Signer())
+	case action == "accept" && !req.incoming:
+		var body acceptRequest
+		if r.ContentLength != 0 {
+			if err := decode(r, &body); err != nil {
+				return nil, err
+			}
+		}
+		issuer := ethwallet.AsEthAddr(conn.Params().Parts[1-conn.Idx()])
+		err = req.accept(ctx, body.Document, issuer, s.client.Address(), s.store)
+	case action == "reject":
+		var body rejectRequest
+		if err := decode(r, &body); err != nil {
+			return nil, err
+		}
+		if req.incoming {
+			err = req.rejectIncoming(ctx, body.Reason)
+		} else {
+			err = req.rejectProposal(ctx, body.Reason)
+		}
+	case action == "issue" || action == "accept":
+		direction := "incoming"
+		if !req.incoming {
+			direction = "outgoing"
+		}
+		return nil, fmt.Errorf("%w: cannot %s an %s request", errBadRequest, action, direction)
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", errNotFound, action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return req.json(), nil
+}
+
+// connection returns the connection with the given hex-encoded channel ID.
+func (s *Server) connection(id string) (*connection.Connection, error) {
+	b, err := hexutil.Decode(id)
+	if err != nil || len(b) != len(channel.ID{}) {
+		return nil, fmt.Errorf("%w: invalid connection ID: %q", errBadRequest, id)
+	}
+	var cid channel.ID
+	copy(cid[:], b)
+	for _, conn := range s.client.Connections() {
+		if conn.ID() == cid {
+			return conn, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: connection %v", errNotFound, id)
+}
+
+// asset returns the asset holder of the given token, or the ETH asset holder
+// if the token is nil.
+func (s *Server) asset(token *common.Address) (common.Address, error) {
+	if token == nil {
+		return s.client.ETHAssetHolder(), nil
+	}
+	ah, ok := s.client.ERC20AssetHolder(*token)
+	if !ok {
+		return common.Address{}, fmt.Errorf("%w: no asset holder for token %v", errBadRequest, *token)
+	}
+	return ah, nil
+}
@@ -0,0 +1,135 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"github.com/stretchr/testify/require"
+	"perun.network/go-perun/channel"
+)
+
+func TestWriteEvent(t *testing.T) {
+	id := channel.ID{0xab}
+	w := httptest.NewRecorder()
+	e := &connection.CredentialIssued{ChannelEvent: connection.ChannelEvent{ChannelID: id}, OfferID: 3, Forced: true}
+	require.NoError(t, writeEvent(w, e))
+
+	lines := strings.Split(w.Body.String(), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, "event: credential_issued", lines[0])
+	require.Equal(t, "", lines[2])
+	require.Equal(t, "", lines[3])
+
+	var je map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &je))
+	require.Equal(t, "credential_issued", je["kind"])
+	require.Equal(t, "0xab00000000000000000000000000000000000000000000000000000000000000", je["connection"])
+	require.EqualValues(t, 3, je["offerId"])
+	require.Equal(t, true, je["forced"])
+	require.NotContains(t, je, "peer")
+}
+
+func TestNewJSONEvent_RequestUpdated(t *testing.T) {
+	je := newJSONEvent(&RequestUpdated{OfferID: 0, Incoming: false, Status: StatusRejected, Reason: "too expensive"})
+	b, err := json.Marshal(je)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"kind": "request_updated",
+		"connection": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"offerId": 0,
+		"incoming": false,
+		"status": "rejected",
+		"reason": "too expensive"
+	}`, string(b))
+}
+
+const testToken = "secret"
+
+func newTestServer() *Server {
+	return &Server{token: testToken, hosts: map[string]bool{"localhost": true, "agent.internal": true}}
+}
+
+// newTestRequest returns an authorized request to the test server.
+func newTestRequest(method, path string) *http.Request {
+	r := httptest.NewRequest(method, "http://localhost:8080"+path, nil)
+	r.Header.Set("Authorization", "Bearer "+testToken)
+	if method == http.MethodPost {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	return r
+}
+
+func TestServeHTTP_Routing(t *testing.T) {
+	s := newTestServer()
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/unknown", http.StatusNotFound},
+		{http.MethodGet, "/connections/0x01/close/extra", http.StatusNotFound},
+		{http.MethodDelete, "/connections", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/connections/0x01/close", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/events", http.StatusMethodNotAllowed},
+	} {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, newTestRequest(tc.method, tc.path))
+		require.Equal(t, tc.status, w.Code, "%s %s", tc.method, tc.path)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestServeHTTP_Access(t *testing.T) {
+	s := newTestServer()
+	for _, tc := range []struct {
+		name   string
+		modify func(r *http.Request)
+		status int
+	}{
+		{"authorized", func(*http.Request) {}, http.StatusNotFound},
+		{"IP host", func(r *http.Request) { r.Host = "127.0.0.1:8080" }, http.StatusNotFound},
+		{"IPv6 host", func(r *http.Request) { r.Host = "[::1]:8080" }, http.StatusNotFound},
+		{"configured host", func(r *http.Request) { r.Host = "Agent.Internal" }, http.StatusNotFound},
+		{"foreign host", func(r *http.Request) { r.Host = "attacker.example:8080" }, http.StatusForbidden},
+		{"missing token", func(r *http.Request) { r.Header.Del("Authorization") }, http.StatusUnauthorized},
+		{"wrong token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer guess") }, http.StatusUnauthorized},
+		{"token without scheme", func(r *http.Request) { r.Header.Set("Authorization", testToken) }, http.StatusUnauthorized},
+		{"text/plain", func(r *http.Request) { r.Header.Set("Content-Type", "text/plain") }, http.StatusUnsupportedMediaType},
+		{"missing content type", func(r *http.Request) { r.Header.Del("Content-Type") }, http.StatusUnsupportedMediaType},
+		{"JSON with charset", func(r *http.Request) { r.Header.Set("Content-Type", "application/json; charset=utf-8") }, http.StatusNotFound},
+	} {
+		r := newTestRequest(http.MethodPost, "/unknown")
+		tc.modify(r)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		require.Equal(t, tc.status, w.Code, tc.name)
+	}
+}
+
+func TestNew_EmptyToken(t *testing.T) {
+	_, err := New(context.Background(), nil, nil, Config{})
+	require.Error(t, err)
+}
+
+func TestRequest_Conflict(t *testing.T) {
+	r := &request{status: StatusPending}
+	require.NoError(t, r.begin(StatusPending))
+	err := r.begin(StatusPending)
+	require.True(t, errors.Is(err, errConflict))
+	require.Equal(t, http.StatusConflict, statusOf(err))
+
+	r.abort()
+	err = r.begin(StatusProposed)
+	require.True(t, errors.Is(err, errConflict))
+}
+
+func TestStatusOf(t *testing.T) {
+	require.Equal(t, http.StatusNotFound, statusOf(errNotFound))
+	require.Equal(t, http.StatusBadRequest, statusOf(errBadRequest))
+	require.Equal(t, http.StatusInternalServerError, statusOf(errors.New("other")))
+}
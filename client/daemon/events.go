@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/perun-network/perun-credential-payment/client/connection"
+)
+
+// streamEvents streams the events of the client and the server as
+// server-sent events until the request is cancelled.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+	clientSub := s.client.Subscribe()
+	defer clientSub.Close()
+	serverSub := s.events.Subscribe()
+	defer serverSub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		var e connection.Event
+		select {
+		case e = <-clientSub.Events():
+		case e = <-serverSub.Events():
+		case <-r.Context().Done():
+			return
+		}
+		if err := writeEvent(w, e); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes the event in the format of server-sent events.
+func writeEvent(w http.ResponseWriter, e connection.Event) error {
+	b, err := json.Marshal(newJSONEvent(e))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Kind(), b)
+	return err
+}
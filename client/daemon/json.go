@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/wallet"
+)
+
+type (
+	connectRequest struct {
+		Peer    common.Address  `json:"peer"`
+		Deposit *hexutil.Big    `json:"deposit"`
+		Token   *common.Address `json:"token,omitempty"`
+	}
+
+	credentialRequest struct {
+		Document hexutil.Bytes   `json:"document"`
+		Price    *hexutil.Big    `json:"price"`
+		Token    *common.Address `json:"token,omitempty"`
+	}
+
+	acceptRequest struct {
+		// Document is only needed for requests that were restored after a
+		// restart, for which the document is unknown.
+		Document hexutil.Bytes `json:"document,omitempty"`
+	}
+
+	rejectRequest struct {
+		Reason string `json:"reason"`
+	}
+
+	jsonConnection struct {
+		ID       common.Hash    `json:"id"`
+		Peer     common.Address `json:"peer"`
+		Phase    string         `json:"phase"`
+		Version  uint64         `json:"version"`
+		Disputed bool           `json:"disputed"`
+		Balances []jsonBalance  `json:"balances"`
+	}
+
+	jsonBalance struct {
+		Asset common.Address `json:"asset"`
+		Ours  *hexutil.Big   `json:"ours"`
+		Peer  *hexutil.Big   `json:"peer"`
+	}
+
+	jsonRequest struct {
+		Connection common.Hash     `json:"connection"`
+		ID         uint64          `json:"id"`
+		Incoming   bool            `json:"incoming"`
+		Status     Status          `json:"status"`
+		Reason     string          `json:"reason,omitempty"`
+		DataHashes []common.Hash   `json:"dataHashes,omitempty"`
+		Prices     []*hexutil.Big  `json:"prices,omitempty"`
+		Credential json.RawMessage `json:"credential,omitempty"`
+	}
+
+	jsonBalances struct {
+		Address     common.Address   `json:"address"`
+		OnChain     *hexutil.Big     `json:"onChain"`
+		Connections []jsonConnection `json:"connections"`
+	}
+
+	jsonError struct {
+		Error string `json:"error"`
+	}
+
+	// jsonEvent is the payload of an event on the event stream. The fields
+	// that an event does not have are omitted.
+	jsonEvent struct {
+		Kind       string          `json:"kind"`
+		Connection *common.Hash    `json:"connection,omitempty"`
+		Peer       *common.Address `json:"peer,omitempty"`
+		OfferID    *uint64         `json:"offerId,omitempty"`
+		Incoming   *bool           `json:"incoming,omitempty"`
+		Quoted     bool            `json:"quoted,omitempty"`
+		Forced     bool            `json:"forced,omitempty"`
+		Reason     string          `json:"reason,omitempty"`
+		Status     Status          `json:"status,omitempty"`
+		Version    *uint64         `json:"version,omitempty"`
+		Timeout    *time.Time      `json:"timeout,omitempty"`
+		Step       string          `json:"step,omitempty"`
+		Attempt    int             `json:"attempt,omitempty"`
+		Failure    string          `json:"failure,omitempty"`
+		Err        string          `json:"error,omitempty"`
+		RetryAt    *time.Time      `json:"retryAt,omitempty"`
+	}
+)
+
+// newJSONEvent converts an event of the client into its JSON payload.
+func newJSONEvent(e connection.Event) *jsonEvent {
+	je := &jsonEvent{Kind: e.Kind()}
+	channel := func(ce connection.ChannelEvent) {
+		id := common.Hash(ce.ChannelID)
+		je.Connection = &id
+	}
+	peer := func(p wallet.Address) {
+		addr := ethwallet.AsEthAddr(p)
+		je.Peer = &addr
+	}
+	offer := func(id uint64) { je.OfferID = &id }
+	version := func(v uint64) { je.Version = &v }
+	optTime := func(t time.Time) *time.Time {
+		if t.IsZero() {
+			return nil
+		}
+		return &t
+	}
+
+	switch e := e.(type) {
+	case *connection.ConnectionProposed:
+		peer(e.Peer)
+	case *connection.ConnectionOpened:
+		channel(e.ChannelEvent)
+		peer(e.Peer)
+	case *connection.OfferReceived:
+		channel(e.ChannelEvent)
+		offer(e.OfferID)
+		je.Quoted = e.Quoted
+	case *connection.CredentialReceived:
+		channel(e.ChannelEvent)
+		offer(e.OfferID)
+	case *connection.CredentialIssued:
+		channel(e.ChannelEvent)
+		offer(e.OfferID)
+		je.Forced = e.Forced
+	case *connection.PaymentAccepted:
+		channel(e.ChannelEvent)
+		offer(e.OfferID)
+	case *connection.PaymentRejected:
+		channel(e.ChannelEvent)
+		offer(e.OfferID)
+		je.Reason = e.Reason
+	case *connection.DisputeRegistered:
+		channel(e.ChannelEvent)
+		version(e.Version)
+		je.Timeout = optTime(e.Timeout)
+	case *connection.DisputeProgressed:
+		channel(e.ChannelEvent)
+		version(e.Version)
+	case *connection.DisputeStepped:
+		channel(e.ChannelEvent)
+		je.Step = string(e.Step)
+		je.Attempt = e.Attempt
+		je.Failure = string(e.Failure)
+		je.Err = e.Err
+		je.RetryAt = optTime(e.RetryAt)
+	case *connection.Concluded:
+		channel(e.ChannelEvent)
+		version(e.Version)
+	case *connection.Settled:
+		channel(e.ChannelEvent)
+	case *RequestUpdated:
+		channel(e.ChannelEvent)
+		offer(e.OfferID)
+		je.Incoming = &e.Incoming
+		je.Status = e.Status
+		je.Reason = e.Reason
+	}
+	return je
+}
@@ -0,0 +1,241 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"github.com/perun-network/perun-credential-payment/client/credstore"
+	"perun.network/go-perun/channel"
+)
+
+// Status is the status of a credential request.
+type Status string
+
+// The statuses of a credential request. Incoming requests go from pending to
+// issued or rejected, outgoing requests from pending to proposed, once the
+// issuer proposed the credential, and then to accepted or rejected.
+const (
+	StatusPending  Status = "pending"
+	StatusProposed Status = "proposed"
+	StatusIssued   Status = "issued"
+	StatusAccepted Status = "accepted"
+	StatusRejected Status = "rejected"
+	StatusFailed   Status = "failed"
+)
+
+// RequestUpdated is emitted when the status of a credential request changes.
+// Reason is set for rejected and failed requests.
+type RequestUpdated struct {
+	connection.ChannelEvent
+	OfferID  uint64
+	Incoming bool
+	Status   Status
+	Reason   string
+}
+
+func (RequestUpdated) Kind() string { return "request_updated" }
+
+// errConflict indicates that an action does not apply to the current status
+// of a request.
+var errConflict = errors.New("conflict")
+
+type requestKey struct {
+	channel channel.ID
+	id      uint64
+}
+
+// request is a credential request that we issue (incoming) or that we sent
+// to an issuer (outgoing).
+type request struct {
+	conn     *connection.Connection
+	id       uint64
+	incoming bool
+	events   *connection.Feed
+
+	mu     sync.Mutex
+	status Status
+	reason string
+	busy   bool // Whether an action is in progress.
+
+	// Incoming requests.
+	req *connection.CredentialRequest
+
+	// Outgoing requests. The document is unknown for restored requests.
+	doc  []byte
+	prop *connection.CredentialsProposal
+	cred []byte // Presentation of the accepted credential.
+}
+
+func (r *request) key() requestKey {
+	return requestKey{r.conn.ID(), r.id}
+}
+
+// begin marks the request as busy if it has the given status.
+func (r *request) begin(want Status) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.busy || r.status != want {
+		return fmt.Errorf("%w: request is %s", errConflict, r.status)
+	}
+	r.busy = true
+	return nil
+}
+
+// end sets the status of the request, clears the busy mark and emits a
+// RequestUpdated event.
+func (r *request) end(s Status, reason string) {
+	r.mu.Lock()
+	r.status, r.reason, r.busy = s, reason, false
+	r.mu.Unlock()
+	r.events.Emit(&RequestUpdated{
+		ChannelEvent: connection.ChannelEvent{ChannelID: r.conn.ID()},
+		OfferID:      r.id,
+		Incoming:     r.incoming,
+		Status:       s,
+		Reason:       reason,
+	})
+}
+
+// abort clears the busy mark without changing the status.
+func (r *request) abort() {
+	r.mu.Lock()
+	r.busy = false
+	r.mu.Unlock()
+}
+
+func (r *request) json() *jsonRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	jr := &jsonRequest{
+		Connection: common.Hash(r.conn.ID()),
+		ID:         r.id,
+		Incoming:   r.incoming,
+		Status:     r.status,
+		Reason:     r.reason,
+		Credential: r.cred,
+	}
+	if r.req != nil {
+		for _, h := range r.req.DataHashes() {
+			jr.DataHashes = append(jr.DataHashes, common.Hash(h))
+		}
+		for _, p := range r.req.Prices() {
+			jr.Prices = append(jr.Prices, (*hexutil.Big)(p))
+		}
+	}
+	if r.prop != nil {
+		jr.Prices = []*hexutil.Big{(*hexutil.Big)(r.prop.Price(0))}
+	}
+	return jr
+}
+
+// issue issues the credential of an incoming request. If the signer does not
+// meet the threshold of the request, the request is not accepted and remains
+// pending, so that it can still be rejected.
+func (r *request) issue(ctx context.Context, signer app.Signer) error {
+	if err := r.begin(StatusPending); err != nil {
+		return err
+	}
+	if err := r.req.IssueCredential(ctx, signer); err != nil {
+		if errors.Is(err, app.ErrThresholdNotMet) {
+			r.abort()
+			return err
+		}
+		r.end(StatusFailed, err.Error())
+		return err
+	}
+	r.end(StatusIssued, "")
+	return nil
+}
+
+// rejectIncoming rejects an incoming request.
+func (r *request) rejectIncoming(ctx context.Context, reason string) error {
+	if err := r.begin(StatusPending); err != nil {
+		return err
+	}
+	if err := r.req.Reject(ctx, reason); err != nil {
+		r.abort()
+		return err
+	}
+	r.end(StatusRejected, reason)
+	return nil
+}
+
+// await waits for the issuer to answer an outgoing request.
+func (r *request) await(ctx context.Context, async *connection.AsyncCredentials) {
+	prop, err := async.Await(ctx)
+	var rejected *connection.RequestRejectedError
+	switch {
+	case errors.As(err, &rejected):
+		r.end(StatusRejected, rejected.Reason)
+	case err != nil:
+		r.end(StatusFailed, err.Error())
+	default:
+		r.mu.Lock()
+		r.prop = prop
+		r.mu.Unlock()
+		r.end(StatusProposed, "")
+	}
+}
+
+// accept verifies the proposed credential of an outgoing request and pays
+// for it. The document must be given if the request was restored. The
+// credential is added to the store if it is not nil.
+func (r *request) accept(ctx context.Context, doc []byte, issuer, holder common.Address, store *credstore.Store) error {
+	if err := r.begin(StatusProposed); err != nil {
+		return err
+	}
+	if doc == nil {
+		doc = r.doc
+	}
+	if doc == nil {
+		r.abort()
+		return errors.New("missing document")
+	} else if len(r.prop.Signatures) != 1 {
+		r.abort()
+		return errors.New("requests for several documents are not supported")
+	}
+	cred := r.prop.Credential(0, doc, nil)
+	if err := cred.Verify(issuer, holder); err != nil {
+		r.abort()
+		return fmt.Errorf("verifying credential: %w", err)
+	}
+	p, err := credstore.MarshalPresentation(cred)
+	if err != nil {
+		r.abort()
+		return fmt.Errorf("encoding credential: %w", err)
+	}
+	if err := r.prop.Accept(ctx); err != nil {
+		r.end(StatusFailed, err.Error())
+		return fmt.Errorf("paying for credential: %w", err)
+	}
+	if store != nil {
+		if err := store.Add(&credstore.Record{Credential: cred, OfferID: r.id, Price: r.prop.Price(0), ReceivedAt: time.Now()}); err != nil {
+			r.conn.Log().Warnf("Storing credential: %v", err)
+		}
+	}
+	r.mu.Lock()
+	r.cred = p
+	r.mu.Unlock()
+	r.end(StatusAccepted, "")
+	return nil
+}
+
+// rejectProposal rejects the credential proposed for an outgoing request.
+func (r *request) rejectProposal(ctx context.Context, reason string) error {
+	if err := r.begin(StatusProposed); err != nil {
+		return err
+	}
+	if err := r.prop.Reject(ctx, reason); err != nil {
+		r.abort()
+		return err
+	}
+	r.end(StatusRejected, reason)
+	return nil
+}
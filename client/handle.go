@@ -1,7 +1,10 @@
 package client
 
 import (
+	"context"
+
 	"github.com/perun-network/perun-credential-payment/client/connection"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
 	"perun.network/go-perun/channel"
 	"perun.network/go-perun/client"
 )
@@ -16,6 +19,15 @@ func (h *handler) HandleProposal(p client.ChannelProposal, r *client.ProposalRes
 		h.Logf("invalid proposal type: %T", p)
 		return
 	}
+	// The app registry is global, so it may resolve apps other than ours.
+	if channel.IsNoApp(lp.App) || !lp.App.Def().Equals(ethwallet.AsWalletAddr(h.appAddress)) {
+		h.Logf("Rejecting proposal with unknown app from %v", lp.Participant)
+		if err := r.Reject(context.TODO(), "unknown app"); err != nil {
+			h.Logf("Rejecting proposal: %v", err)
+		}
+		return
+	}
+	h.events.Emit(&connection.ConnectionProposed{Peer: lp.Participant})
 	h.channelProposals <- connection.NewChannelProposal(lp, r)
 }
 
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	pkgapp "github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"github.com/perun-network/perun-credential-payment/client/perun"
+	"github.com/stretchr/testify/require"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/backend/ethereum/wallet/simple"
+	"perun.network/go-perun/channel"
+	"perun.network/go-perun/client"
+	ctest "perun.network/go-perun/client/test"
+	"perun.network/go-perun/watcher/local"
+	"perun.network/go-perun/wire"
+)
+
+// newPerunClient returns a perun client with a mocked backend that is
+// connected to the given bus.
+func newPerunClient(t *testing.T, bus wire.Bus) (*client.Client, *simple.Account) {
+	t.Helper()
+	require := require.New(t)
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	w := simple.NewWallet(key)
+	acc, err := w.Unlock(ethwallet.AsWalletAddr(crypto.PubkeyToAddress(key.PublicKey)))
+	require.NoError(err)
+
+	backend := ctest.NewMockBackend(rand.New(rand.NewSource(1)))
+	watcher, err := local.NewWatcher(backend)
+	require.NoError(err)
+	c, err := client.New(acc.Address(), bus, backend, backend, w, watcher)
+	require.NoError(err)
+	t.Cleanup(func() { c.Close() })
+	return c, acc.(*simple.Account)
+}
+
+func TestHandleProposal_ForeignApp(t *testing.T) {
+	require := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ours, foreign := common.Address{1}, common.Address{2}
+	channel.RegisterApp(pkgapp.NewCredentialSwapApp(ethwallet.AsWalletAddr(ours)))
+	channel.RegisterApp(pkgapp.NewCredentialSwapApp(ethwallet.AsWalletAddr(foreign)))
+
+	bus := wire.NewLocalBus()
+	responder, acc := newPerunClient(t, bus)
+	proposer, propAcc := newPerunClient(t, bus)
+	h := &handler{Client: &Client{
+		perunClient:      &perun.Client{Account: acc},
+		appAddress:       ours,
+		channelProposals: make(chan *connection.ChannelProposal, 1),
+		events:           connection.NewFeed(),
+	}}
+	go responder.Handle(h, h)
+
+	// The proposal uses an app that the registry resolves, but that is not
+	// the app of the client.
+	app := pkgapp.NewCredentialSwapApp(ethwallet.AsWalletAddr(foreign))
+	alloc := channel.NewAllocation(2, ethwallet.AsWalletAddr(common.Address{3}))
+	alloc.Balances[0][0] = big.NewInt(1)
+	alloc.Balances[0][1] = big.NewInt(0)
+	prop, err := client.NewLedgerChannelProposal(
+		60,
+		propAcc.Address(),
+		alloc,
+		[]wire.Address{propAcc.Address(), acc.Address()},
+		client.WithApp(app, app.InitData()),
+	)
+	require.NoError(err)
+
+	_, err = proposer.ProposeChannel(ctx, prop)
+	var rejected client.PeerRejectedError
+	require.True(errors.As(err, &rejected), "proposal not rejected: %v", err)
+	require.Equal("unknown app", rejected.Reason)
+	require.Empty(h.channelProposals)
+}
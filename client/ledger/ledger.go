@@ -0,0 +1,254 @@
+// Package ledger implements the issuer's issuance ledger. It records the
+// outcome of every attempt to issue credentials, one entry per document, and
+// exports the entries for bookkeeping and reconciliation.
+package ledger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"perun.network/go-perun/pkg/sortedkv"
+	"perun.network/go-perun/pkg/sortedkv/leveldb"
+)
+
+const entryPrefix = "issuance:"
+
+// Settlement describes how the payment for a credential was settled.
+type Settlement string
+
+const (
+	// OffChain means that the holder accepted the credential off-chain.
+	OffChain Settlement = "off-chain"
+	// OnChain means that the credential was issued by forcing the update
+	// on-chain.
+	OnChain Settlement = "on-chain"
+	// Failed means that the credential was not issued.
+	Failed Settlement = "failed"
+)
+
+// Entry is the ledger entry of a single document. WithdrawnAt is set once the
+// channel was concluded and the funds were withdrawn. SettleError is the error
+// of the last failed attempt to settle the channel.
+type Entry struct {
+	Time        time.Time      `json:"time"`
+	ChannelID   common.Hash    `json:"channelId"`
+	OfferID     uint64         `json:"offerId"`
+	Holder      common.Address `json:"holder"`
+	Asset       common.Address `json:"asset"`
+	DocHash     common.Hash    `json:"docHash"`
+	Price       *big.Int       `json:"price"`
+	Settlement  Settlement     `json:"settlement"`
+	Error       string         `json:"error,omitempty"`
+	WithdrawnAt *time.Time     `json:"withdrawnAt,omitempty"`
+	SettleError string         `json:"settleError,omitempty"`
+}
+
+// Ledger is a durable issuance ledger.
+type Ledger struct {
+	mu  sync.Mutex
+	db  sortedkv.Database
+	seq uint64
+}
+
+var _ connection.IssuanceLog = (*Ledger)(nil)
+
+// Open opens the ledger backed by the LevelDB database at the given path.
+func Open(path string) (*Ledger, error) {
+	db, err := leveldb.LoadDatabase(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading database: %w", err)
+	}
+	return New(db), nil
+}
+
+// New returns a ledger backed by the given database.
+func New(db sortedkv.Database) *Ledger {
+	return &Ledger{db: db}
+}
+
+// Close closes the underlying database.
+func (l *Ledger) Close() error {
+	return l.db.Close()
+}
+
+// RecordIssuance adds an entry for each document of the given issuance.
+func (l *Ledger) RecordIssuance(is *connection.Issuance) error {
+	settlement, errMsg := OffChain, ""
+	if is.Err != nil {
+		settlement, errMsg = Failed, is.Err.Error()
+	} else if is.Forced {
+		settlement = OnChain
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	batch := l.db.NewBatch()
+	for i, h := range is.DocHashes {
+		e := &Entry{
+			Time:       is.Time.UTC(),
+			ChannelID:  common.Hash(is.ChannelID),
+			OfferID:    is.OfferID,
+			Holder:     is.Holder,
+			Asset:      is.Asset,
+			DocHash:    common.Hash(h),
+			Price:      is.Prices[i],
+			Settlement: settlement,
+			Error:      errMsg,
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("encoding entry: %w", err)
+		}
+		// Keys are ordered by time. The sequence number distinguishes
+		// entries recorded at the same time.
+		l.seq++
+		key := fmt.Sprintf("%s%020d:%010d", entryPrefix, e.Time.UnixNano(), l.seq)
+		if err := batch.PutBytes(key, b); err != nil {
+			return err
+		}
+	}
+	return batch.Apply()
+}
+
+// RecordSettlement updates the entries of the settled channel with the
+// outcome of the settlement. Entries of failed issuances and entries that
+// were already withdrawn are not updated.
+func (l *Ledger) RecordSettlement(s *connection.ChannelSettlement) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	batch := l.db.NewBatch()
+	err := l.forEach(func(key string, e *Entry) error {
+		if e.ChannelID != common.Hash(s.ChannelID) || e.Settlement == Failed || e.WithdrawnAt != nil {
+			return nil
+		}
+		if s.Err != nil {
+			e.SettleError = s.Err.Error()
+		} else {
+			t := s.Time.UTC()
+			e.WithdrawnAt, e.SettleError = &t, ""
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("encoding entry: %w", err)
+		}
+		return batch.PutBytes(key, b)
+	})
+	if err != nil {
+		return err
+	}
+	return batch.Apply()
+}
+
+// Entries returns all entries in the order in which they were recorded.
+func (l *Ledger) Entries() (entries []*Entry, err error) {
+	err = l.forEach(func(_ string, e *Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// forEach calls fn for each entry in the order in which they were recorded.
+func (l *Ledger) forEach(fn func(key string, e *Entry) error) (err error) {
+	it := l.db.NewIteratorWithPrefix(entryPrefix)
+	defer func() {
+		if cerr := it.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	for it.Next() {
+		e := new(Entry)
+		if err := json.Unmarshal(it.ValueBytes(), e); err != nil {
+			return fmt.Errorf("decoding entry %s: %w", it.Key(), err)
+		}
+		if err := fn(it.Key(), e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes all entries as a JSON array to w.
+func (l *Ledger) WriteJSON(w io.Writer) error {
+	entries, err := l.Entries()
+	if err != nil {
+		return err
+	}
+	if entries == nil {
+		entries = []*Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+var csvHeader = []string{
+	"time", "channel_id", "offer_id", "holder", "asset", "doc_hash", "price", "settlement", "error",
+	"withdrawn_at", "settle_error",
+}
+
+// WriteCSV writes all entries as CSV with a header row to w. Prices are given
+// in the smallest unit of the asset.
+func (l *Ledger) WriteCSV(w io.Writer) error {
+	entries, err := l.Entries()
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		withdrawnAt := ""
+		if e.WithdrawnAt != nil {
+			withdrawnAt = e.WithdrawnAt.Format(time.RFC3339Nano)
+		}
+		err := cw.Write([]string{
+			e.Time.Format(time.RFC3339Nano),
+			e.ChannelID.Hex(),
+			strconv.FormatUint(e.OfferID, 10),
+			e.Holder.Hex(),
+			e.Asset.Hex(),
+			e.DocHash.Hex(),
+			e.Price.String(),
+			string(e.Settlement),
+			e.Error,
+			withdrawnAt,
+			e.SettleError,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Total returns the sum of the prices of the withdrawn entries for the given
+// asset, which can be reconciled against the on-chain withdrawals.
+func (l *Ledger) Total(asset common.Address) (*big.Int, error) {
+	entries, err := l.Entries()
+	if err != nil {
+		return nil, err
+	}
+	sum := new(big.Int)
+	for _, e := range entries {
+		if e.Asset == asset && e.WithdrawnAt != nil {
+			sum.Add(sum, e.Price)
+		}
+	}
+	return sum, nil
+}
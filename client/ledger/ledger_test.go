@@ -0,0 +1,94 @@
+package ledger_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"github.com/perun-network/perun-credential-payment/client/ledger"
+	"github.com/stretchr/testify/require"
+	"perun.network/go-perun/pkg/sortedkv/memorydb"
+)
+
+func TestLedger(t *testing.T) {
+	require := require.New(t)
+	l := ledger.New(memorydb.NewDatabase())
+
+	asset := common.Address{1}
+	now := time.Unix(1_700_000_000, 0)
+	issuance := func(offerID uint64, forced bool, err error, prices ...int64) *connection.Issuance {
+		is := &connection.Issuance{
+			Time:      now.Add(time.Duration(offerID) * time.Second),
+			ChannelID: [32]byte{2},
+			OfferID:   offerID,
+			Holder:    common.Address{3},
+			Asset:     asset,
+			Forced:    forced,
+			Err:       err,
+		}
+		for i, p := range prices {
+			is.DocHashes = append(is.DocHashes, app.Hash{byte(offerID), byte(i)})
+			is.Prices = append(is.Prices, big.NewInt(p))
+		}
+		return is
+	}
+	require.NoError(l.RecordIssuance(issuance(1, false, nil, 5, 7)))
+	require.NoError(l.RecordIssuance(issuance(2, true, nil, 3)))
+	require.NoError(l.RecordIssuance(issuance(3, false, errors.New("offer expired"), 11)))
+
+	entries, err := l.Entries()
+	require.NoError(err)
+	require.Len(entries, 4)
+	require.Equal(ledger.OffChain, entries[0].Settlement)
+	require.Equal(ledger.OnChain, entries[2].Settlement)
+	require.Equal(ledger.Failed, entries[3].Settlement)
+	require.Equal("offer expired", entries[3].Error)
+
+	// Only withdrawn entries count towards the total.
+	total, err := l.Total(asset)
+	require.NoError(err)
+	require.Zero(total.Sign())
+
+	settled := now.Add(time.Minute)
+	require.NoError(l.RecordSettlement(&connection.ChannelSettlement{
+		Time: settled, ChannelID: [32]byte{2}, Err: errors.New("out of gas"),
+	}))
+	entries, err = l.Entries()
+	require.NoError(err)
+	require.Nil(entries[0].WithdrawnAt)
+	require.Equal("out of gas", entries[0].SettleError)
+
+	require.NoError(l.RecordSettlement(&connection.ChannelSettlement{Time: settled, ChannelID: [32]byte{2}}))
+	entries, err = l.Entries()
+	require.NoError(err)
+	for _, e := range entries[:3] {
+		require.True(settled.Equal(*e.WithdrawnAt))
+		require.Empty(e.SettleError)
+	}
+	require.Nil(entries[3].WithdrawnAt, "failed issuance withdrawn")
+
+	total, err = l.Total(asset)
+	require.NoError(err)
+	require.Equal(big.NewInt(15), total)
+
+	var buf bytes.Buffer
+	require.NoError(l.WriteCSV(&buf))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(err)
+	require.Len(rows, 5)
+	require.Equal("price", rows[0][6])
+	require.Equal("7", rows[2][6])
+
+	buf.Reset()
+	require.NoError(l.WriteJSON(&buf))
+	var decoded []*ledger.Entry
+	require.NoError(json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(entries, decoded)
+}
@@ -16,7 +16,10 @@ import (
 	"perun.network/go-perun/backend/ethereum/channel"
 	"perun.network/go-perun/backend/ethereum/wallet"
 	wtest "perun.network/go-perun/backend/ethereum/wallet/simple"
+	"perun.network/go-perun/channel/persistence"
+	"perun.network/go-perun/channel/persistence/keyvalue"
 	"perun.network/go-perun/client"
+	"perun.network/go-perun/pkg/sortedkv/leveldb"
 	"perun.network/go-perun/watcher/local"
 	"perun.network/go-perun/wire"
 	"perun.network/go-perun/wire/net"
@@ -28,16 +31,26 @@ type Peer struct {
 	Address string
 }
 
+// AssetHolderERC20 describes an ERC20 asset holder and the token it holds.
+type AssetHolderERC20 struct {
+	AssetHolder common.Address
+	Token       common.Address
+}
+
 type ClientConfig struct {
-	PrivateKey    *ecdsa.PrivateKey
-	Host          string
-	ETHNodeURL    string
-	Adjudicator   common.Address
-	AssetHolder   common.Address
-	DialerTimeout time.Duration
-	Peers         []Peer
-	TxFinality    uint64
-	ChainID       *big.Int
+	PrivateKey        *ecdsa.PrivateKey
+	Host              string
+	ETHNodeURL        string
+	Adjudicator       common.Address
+	AssetHolder       common.Address
+	AssetHoldersERC20 []AssetHolderERC20
+	DialerTimeout     time.Duration
+	Peers             []Peer
+	TxFinality        uint64
+	ChainID           *big.Int
+	// DatabasePath is the directory of the LevelDB database in which the
+	// channels are persisted. If empty, channels are not persisted.
+	DatabasePath string
 }
 
 type Client struct {
@@ -48,9 +61,11 @@ type Client struct {
 	ContractBackend channel.ContractInterface
 	Wallet          *wtest.Wallet
 	Account         *wtest.Account
+	// PersistRestorer is nil if persistence is disabled.
+	PersistRestorer persistence.PersistRestorer
 }
 
-func SetupClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
+func SetupClient(ctx context.Context, cfg ClientConfig) (_ *Client, err error) {
 	// Create wallet and account
 	w := wtest.NewWallet(cfg.PrivateKey)
 	addr := wallet.AsWalletAddr(crypto.PubkeyToAddress(cfg.PrivateKey.PublicKey))
@@ -72,14 +87,26 @@ func SetupClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
 	}
 	adjudicator := channel.NewAdjudicator(cb, cfg.Adjudicator, account.Account.Address, account.Account)
 
-	// Setup asset holder.
-	funder := createFunder(cb, account.Account, cfg.AssetHolder)
+	// Setup asset holders.
+	funder := createFunder(cb, account.Account, cfg.AssetHolder, cfg.AssetHoldersERC20)
 
 	// Setup network.
 	listener, bus, err := setupNetwork(account, cfg.Host, cfg.Peers, cfg.DialerTimeout)
 	if err != nil {
 		return nil, errors.WithMessage(err, "setting up network")
 	}
+	// Release the network if the remaining setup fails.
+	var c *client.Client
+	defer func() {
+		if err == nil {
+			return
+		}
+		if c != nil {
+			c.Close()
+		}
+		bus.Close()
+		listener.Close()
+	}()
 
 	// Setup watcher.
 	watcher, err := local.NewWatcher(adjudicator)
@@ -88,12 +115,38 @@ func SetupClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
 	}
 
 	// Initialize Perun client.
-	c, err := client.New(account.Address(), bus, funder, adjudicator, w, watcher)
+	c, err = client.New(account.Address(), bus, funder, adjudicator, w, watcher)
 	if err != nil {
 		return nil, errors.WithMessage(err, "initializing client")
 	}
 
-	return &Client{ethClient, c, bus, listener, cb, w, account}, nil
+	// Setup persistence.
+	var pr persistence.PersistRestorer
+	if cfg.DatabasePath != "" {
+		db, err := leveldb.LoadDatabase(cfg.DatabasePath)
+		if err != nil {
+			return nil, fmt.Errorf("loading database: %w", err)
+		}
+		pr = keyvalue.NewPersistRestorer(db)
+		c.EnablePersistence(pr)
+	}
+
+	return &Client{ethClient, c, bus, listener, cb, w, account, pr}, nil
+}
+
+// Close closes a client whose bus is not listening yet. It releases the
+// network listener and the database and returns the first error.
+func (c *Client) Close() error {
+	errs := []error{c.PerunClient.Close(), c.Bus.Close(), c.Listener.Close()}
+	if c.PersistRestorer != nil {
+		errs = append(errs, c.PersistRestorer.Close())
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func createContractBackend(nodeURL string, wallet *wtest.Wallet, chainID *big.Int, txFinality uint64) (*ethclient.Client, channel.ContractBackend, error) {
@@ -125,10 +178,15 @@ func setupNetwork(account wire.Account, host string, peerAddresses []Peer, diale
 	return listener, bus, nil
 }
 
-func createFunder(cb channel.ContractBackend, account accounts.Account, assetHolder common.Address) *channel.Funder {
+func createFunder(cb channel.ContractBackend, account accounts.Account, assetHolder common.Address, assetHoldersERC20 []AssetHolderERC20) *channel.Funder {
 	f := channel.NewFunder(cb)
 	asset := wallet.Address(assetHolder)
 	depositor := new(channel.ETHDepositor)
 	f.RegisterAsset(asset, depositor, account)
+	for _, ah := range assetHoldersERC20 {
+		asset := wallet.Address(ah.AssetHolder)
+		depositor := channel.NewERC20Depositor(ah.Token)
+		f.RegisterAsset(asset, depositor, account)
+	}
 	return f
 }
@@ -18,6 +18,34 @@ func (c *Client) Address() common.Address {
 	return c.perunClient.Account.Account.Address
 }
 
+// ETHAssetHolder returns the address of the ETH asset holder.
+func (c *Client) ETHAssetHolder() common.Address {
+	return c.assetHolderAddr
+}
+
+// ERC20AssetHolder returns the address of the asset holder for the given
+// token.
+func (c *Client) ERC20AssetHolder(token common.Address) (common.Address, bool) {
+	for _, ah := range c.assetHoldersERC20 {
+		if ah.Token == token {
+			return ah.AssetHolder, true
+		}
+	}
+	return common.Address{}, false
+}
+
+func (c *Client) isAssetHolder(addr common.Address) bool {
+	if addr == c.assetHolderAddr {
+		return true
+	}
+	for _, ah := range c.assetHoldersERC20 {
+		if ah.AssetHolder == addr {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Client) challengeDurationInSeconds() uint64 {
 	return uint64(c.challengeDuration.Seconds())
 }
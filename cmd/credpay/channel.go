@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func channelsList(ctx context.Context, args []string) error {
+	var cf clientFlags
+	fs := flag.NewFlagSet("channels list", flag.ExitOnError)
+	cf.register(fs)
+	fs.Parse(args)
+
+	c, err := cf.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Shutdown()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANNEL\tPEER\tPHASE\tVERSION\tBALANCES")
+	for _, conn := range c.Connections() {
+		s := conn.State()
+		var bals []string
+		for i, a := range s.Assets {
+			bals = append(bals, fmt.Sprintf("%s/%s@%v",
+				formatEth(s.Balances[i][conn.Idx()]),
+				formatEth(s.Balances[i][1-conn.Idx()]),
+				a))
+		}
+		fmt.Fprintf(w, "%x\t%v\t%v\t%d\t%s\n", conn.ID(), peerOf(conn), conn.Phase(), s.Version, strings.Join(bals, " "))
+	}
+	return w.Flush()
+}
+
+func channelClose(ctx context.Context, args []string) error {
+	var (
+		cf      clientFlags
+		channel string
+	)
+	fs := flag.NewFlagSet("channel close", flag.ExitOnError)
+	cf.register(fs)
+	fs.StringVar(&channel, "channel", "", "ID of the connection")
+	fs.Parse(args)
+
+	c, err := cf.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Shutdown()
+
+	conn, err := findConnection(c, channel)
+	if err != nil {
+		return err
+	}
+	if err := conn.Close(ctx); err != nil {
+		return fmt.Errorf("closing connection: %w", err)
+	}
+	fmt.Printf("Settled connection %x\n", conn.ID())
+	return nil
+}
+
+func balance(ctx context.Context, args []string) error {
+	var cf clientFlags
+	fs := flag.NewFlagSet("balance", flag.ExitOnError)
+	cf.register(fs)
+	fs.Parse(args)
+
+	c, err := cf.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Shutdown()
+
+	onChain, err := c.OnChainBalance()
+	if err != nil {
+		return fmt.Errorf("reading on-chain balance: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "account\t%v\t%s ETH\n", c.Address(), formatEth(onChain))
+	for _, conn := range c.Connections() {
+		s := conn.State()
+		for i, a := range s.Assets {
+			fmt.Fprintf(w, "channel\t%x\t%s @%v\n", conn.ID(), formatEth(s.Balances[i][conn.Idx()]), a)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/perun-network/perun-credential-payment/client/credstore"
+	"github.com/perun-network/perun-credential-payment/client/daemon"
+)
+
+func runDaemon(ctx context.Context, args []string) error {
+	var (
+		cf        clientFlags
+		listen    string
+		store     string
+		tokenFile string
+		hosts     stringList
+	)
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	cf.register(fs)
+	fs.StringVar(&listen, "listen", "127.0.0.1:8080", "listen address of the HTTP API")
+	fs.StringVar(&store, "store", "credpay-credentials", "directory of the credential store")
+	fs.StringVar(&tokenFile, "token-file", "", "file containing the bearer token of the HTTP API")
+	fs.Var(&hosts, "host", "host name by which the HTTP API may be addressed besides localhost and IP addresses (repeatable)")
+	fs.Parse(args)
+
+	if tokenFile == "" {
+		return errors.New("missing -token-file")
+	}
+	b, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return fmt.Errorf("reading token: %w", err)
+	}
+	cfg := daemon.Config{Token: strings.TrimSpace(string(b)), Hosts: hosts}
+
+	s, err := credstore.Open(store)
+	if err != nil {
+		return fmt.Errorf("opening credential store: %w", err)
+	}
+	defer s.Close()
+
+	c, err := cf.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Shutdown()
+
+	d, err := daemon.New(ctx, c, s, cfg)
+	if err != nil {
+		return fmt.Errorf("creating daemon: %w", err)
+	}
+	srv := &http.Server{Addr: listen, Handler: d}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	log.Printf("Serving API of %v on %s", c.Address(), listen)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/pkg/deploy"
+)
+
+func deployContracts(ctx context.Context, args []string) error {
+	var (
+		cf     clientFlags
+		out    string
+		tokens stringList
+	)
+	fs := flag.NewFlagSet("deploy", flag.ExitOnError)
+	cf.register(fs)
+	fs.StringVar(&out, "out", "credpay-contracts.json", "`file` to which the manifest is written; contracts listed in it are reused")
+	fs.Var(&tokens, "token", "`address` of a token for which an ERC20 asset holder is deployed (repeatable)")
+	fs.Parse(args)
+
+	file, err := cf.file()
+	if err != nil {
+		return err
+	}
+	key, err := file.Key.PrivateKey()
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+	existing, err := existingContracts(out, file.Manifest)
+	if err != nil {
+		return err
+	}
+	var opts deploy.Options
+	for _, t := range tokens {
+		addr, err := parseAddress("token", t)
+		if err != nil {
+			return err
+		}
+		opts.Tokens = append(opts.Tokens, addr)
+	}
+	// Addresses given explicitly take precedence over the manifest.
+	c := file.Contracts
+	for _, a := range []struct {
+		name string
+		s    string
+		addr *common.Address
+	}{
+		{"adjudicator", c.Adjudicator, &existing.Adjudicator},
+		{"asset holder", c.AssetHolder, &existing.AssetHolder},
+		{"app", c.App, &existing.App},
+	} {
+		if a.s == "" {
+			continue
+		}
+		if *a.addr, err = parseAddress(a.name, a.s); err != nil {
+			return err
+		}
+	}
+	for _, e := range c.ERC20 {
+		existing.ERC20 = append(existing.ERC20, deploy.ERC20{
+			AssetHolder: common.HexToAddress(e.AssetHolder),
+			Token:       common.HexToAddress(e.Token),
+		})
+	}
+
+	d, err := deploy.NewDeployer(ctx, file.Node, key, new(big.Int).SetUint64(file.ChainID))
+	if err != nil {
+		return fmt.Errorf("connecting to node: %w", err)
+	}
+	defer d.Close()
+	m, err := deploy.Deploy(ctx, d, existing, opts)
+	if err != nil {
+		return err
+	}
+	if err := m.Write(out); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	fmt.Printf("adjudicator  %v\nassetholder  %v\napp          %v\n", m.Adjudicator, m.AssetHolder, m.App)
+	for _, e := range m.ERC20 {
+		fmt.Printf("erc20        %v=%v\n", e.AssetHolder, e.Token)
+	}
+	return nil
+}
+
+// existingContracts reads the manifest at out, or else the one at manifest
+// if it is set. A missing manifest at out is not an error.
+func existingContracts(out, manifest string) (*deploy.Manifest, error) {
+	m, err := deploy.ReadManifest(out)
+	if err == nil {
+		return m, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if manifest == "" {
+		return new(deploy.Manifest), nil
+	}
+	return deploy.ReadManifest(manifest)
+}
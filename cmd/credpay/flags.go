@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/client"
+	"github.com/perun-network/perun-credential-payment/client/config"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"perun.network/go-perun/channel"
+)
+
+// keyEnv is the environment variable from which the private key is read if
+// neither a key file nor a config file is given.
+const keyEnv = "CREDPAY_PRIVATE_KEY"
+
+// passwordEnv is the environment variable from which the keystore password
+// is read if no password file is given.
+const passwordEnv = "CREDPAY_PASSWORD"
+
+// clientFlags are the flags that configure the client. If a config file is
+// given, the flags that are set explicitly override its values.
+type clientFlags struct {
+	fs          *flag.FlagSet
+	configFile  string
+	node        string
+	chainID     uint64
+	keyFile     string
+	keystore    string
+	password    string
+	mnemonic    string
+	hdPath      string
+	host        string
+	db          string
+	adjudicator string
+	assetHolder string
+	app         string
+	manifest    string
+	erc20       erc20List
+	peers       peerList
+	challenge   time.Duration
+	txFinality  uint64
+}
+
+func (f *clientFlags) register(fs *flag.FlagSet) {
+	f.fs = fs
+	fs.StringVar(&f.configFile, "config", "", "configuration `file`, see package client/config")
+	fs.StringVar(&f.node, "node", "ws://127.0.0.1:8545", "URL of the Ethereum node")
+	fs.Uint64Var(&f.chainID, "chain-id", 1337, "chain ID")
+	fs.StringVar(&f.keyFile, "key-file", "", "file containing the hex-encoded private key (default: $"+keyEnv+")")
+	fs.StringVar(&f.keystore, "keystore", "", "encrypted keystore `file` containing the private key")
+	fs.StringVar(&f.password, "password-file", "", "file containing the keystore password (default: $"+passwordEnv+")")
+	fs.StringVar(&f.mnemonic, "mnemonic-file", "", "file containing the BIP-39 mnemonic from which the key is derived")
+	fs.StringVar(&f.hdPath, "hd-path", config.DefaultDerivationPath, "derivation path of the key for -mnemonic-file")
+	fs.StringVar(&f.host, "host", "127.0.0.1:8546", "listen address for peer connections")
+	fs.StringVar(&f.db, "db", "credpay-db", "directory of the connection database")
+	fs.StringVar(&f.adjudicator, "adjudicator", "", "address of the adjudicator contract")
+	fs.StringVar(&f.assetHolder, "assetholder", "", "address of the ETH asset holder contract")
+	fs.StringVar(&f.app, "app", "", "address of the CredentialSwap app contract")
+	fs.StringVar(&f.manifest, "manifest", "", "contract address manifest `file` written by credpay deploy")
+	fs.Var(&f.erc20, "erc20", "ERC20 asset holder as `assetholder=token` (repeatable)")
+	fs.Var(&f.peers, "peer", "peer as `address@host:port` (repeatable)")
+	fs.DurationVar(&f.challenge, "challenge-duration", config.DefaultChallengeDuration, "challenge duration of new connections")
+	fs.Uint64Var(&f.txFinality, "tx-finality", config.DefaultTxFinality, "number of blocks after which a transaction is final")
+}
+
+// file returns the config file with the values of the flags applied.
+func (f *clientFlags) file() (*config.File, error) {
+	file := &config.File{DialerTimeout: config.DefaultDialerTimeout}
+	if f.configFile != "" {
+		var err error
+		if file, err = config.Read(f.configFile); err != nil {
+			return nil, err
+		}
+	}
+
+	set := make(map[string]bool)
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	use := func(name string) bool { return f.configFile == "" || set[name] }
+	if use("node") {
+		file.Node = f.node
+	}
+	if use("chain-id") {
+		file.ChainID = f.chainID
+	}
+	if use("host") {
+		file.Host = f.host
+	}
+	if use("db") {
+		file.Database = f.db
+	}
+	if use("adjudicator") {
+		file.Contracts.Adjudicator = f.adjudicator
+	}
+	if use("assetholder") {
+		file.Contracts.AssetHolder = f.assetHolder
+	}
+	if use("app") {
+		file.Contracts.App = f.app
+	}
+	if use("manifest") {
+		file.Manifest = f.manifest
+	}
+	if use("erc20") {
+		file.Contracts.ERC20 = f.erc20
+	}
+	if use("peer") {
+		file.Peers = f.peers
+	}
+	if use("challenge-duration") {
+		file.ChallengeDuration = f.challenge
+	}
+	if use("tx-finality") {
+		file.TxFinality = f.txFinality
+	}
+	switch {
+	case f.keyFile != "":
+		file.Key = config.KeySource{File: f.keyFile}
+	case f.keystore != "":
+		password := config.Secret{File: f.password}
+		if f.password == "" {
+			password.Env = passwordEnv
+		}
+		file.Key = config.KeySource{Keystore: &config.KeystoreSource{File: f.keystore, Password: password}}
+	case f.mnemonic != "":
+		file.Key = config.KeySource{Mnemonic: &config.MnemonicSource{
+			Phrase: config.Secret{File: f.mnemonic},
+			Path:   f.hdPath,
+		}}
+	case f.configFile == "":
+		file.Key = config.KeySource{Env: keyEnv}
+	}
+	return file, nil
+}
+
+func (f *clientFlags) config() (client.ClientConfig, error) {
+	file, err := f.file()
+	if err != nil {
+		return client.ClientConfig{}, err
+	}
+	cfg, err := file.ClientConfig()
+	if err != nil {
+		return client.ClientConfig{}, err
+	}
+	return cfg, nil
+}
+
+// start starts the client configured by the flags.
+func (f *clientFlags) start(ctx context.Context) (*client.Client, error) {
+	cfg, err := f.config()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.StartClient(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("starting client: %w", err)
+	}
+	return c, nil
+}
+
+// peerList is a flag.Value holding peers given as address@host:port.
+type peerList []config.Peer
+
+func (l *peerList) String() string {
+	peers := make([]string, len(*l))
+	for i, p := range *l {
+		peers[i] = p.Address + "@" + p.Host
+	}
+	return strings.Join(peers, ",")
+}
+
+func (l *peerList) Set(s string) error {
+	i := strings.LastIndex(s, "@")
+	if i < 0 {
+		return errors.New("expected address@host:port")
+	}
+	if _, err := parseAddress("peer", s[:i]); err != nil {
+		return err
+	}
+	*l = append(*l, config.Peer{Address: s[:i], Host: s[i+1:]})
+	return nil
+}
+
+// erc20List is a flag.Value holding ERC20 asset holders given as
+// assetholder=token.
+type erc20List []config.ERC20
+
+func (l *erc20List) String() string {
+	holders := make([]string, len(*l))
+	for i, ah := range *l {
+		holders[i] = ah.AssetHolder + "=" + ah.Token
+	}
+	return strings.Join(holders, ",")
+}
+
+func (l *erc20List) Set(s string) error {
+	parts := strings.Split(s, "=")
+	if len(parts) != 2 {
+		return errors.New("expected assetholder=token")
+	}
+	if _, err := parseAddress("asset holder", parts[0]); err != nil {
+		return err
+	}
+	if _, err := parseAddress("token", parts[1]); err != nil {
+		return err
+	}
+	*l = append(*l, config.ERC20{AssetHolder: parts[0], Token: parts[1]})
+	return nil
+}
+
+func parseAddress(name, s string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid %s address: %q", name, s)
+	}
+	return common.HexToAddress(s), nil
+}
+
+// parseEth parses an amount of ETH, or of a token with 18 decimals, into its
+// smallest unit.
+func parseEth(s string) (*big.Int, error) {
+	f, ok := new(big.Float).SetPrec(256).SetString(s)
+	if !ok || f.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount: %q", s)
+	}
+	wei, _ := f.Mul(f, big.NewFloat(1e18)).Int(nil)
+	return wei, nil
+}
+
+// formatEth formats an amount in the smallest unit as ETH.
+func formatEth(wei *big.Int) string {
+	f := new(big.Float).SetPrec(256).SetInt(wei)
+	return f.Quo(f, big.NewFloat(1e18)).Text('f', -1)
+}
+
+func parseChannelID(s string) (channel.ID, error) {
+	var id channel.ID
+	b := common.FromHex(s)
+	if len(b) != len(id) {
+		return id, fmt.Errorf("invalid channel ID: %q", s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// findConnection returns the connection of the client with the given channel
+// ID.
+func findConnection(c *client.Client, s string) (*connection.Connection, error) {
+	id, err := parseChannelID(s)
+	if err != nil {
+		return nil, err
+	}
+	for _, conn := range c.Connections() {
+		if conn.ID() == id {
+			return conn, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown channel: %x", id)
+}
+
+// asset returns the asset holder for the given token, or the ETH asset holder
+// if the token is empty.
+func asset(c *client.Client, token string) (common.Address, error) {
+	if token == "" {
+		return c.ETHAssetHolder(), nil
+	}
+	addr, err := parseAddress("token", token)
+	if err != nil {
+		return common.Address{}, err
+	}
+	ah, ok := c.ERC20AssetHolder(addr)
+	if !ok {
+		return common.Address{}, fmt.Errorf("no asset holder for token %v", addr)
+	}
+	return ah, nil
+}
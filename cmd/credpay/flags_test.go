@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEth(t *testing.T) {
+	wei, err := parseEth("1.5")
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(15e17), wei)
+	require.Equal(t, "1.5", formatEth(wei))
+
+	_, err = parseEth("-1")
+	require.Error(t, err)
+	_, err = parseEth("")
+	require.Error(t, err)
+}
+
+func TestPeerList(t *testing.T) {
+	var l peerList
+	addr := common.HexToAddress("0x05e71027e7d2bd6dc2b8cf3b1e8d7a7ac6b5c2b4")
+	require.NoError(t, l.Set(addr.Hex()+"@127.0.0.1:8547"))
+	require.Len(t, l, 1)
+	require.Equal(t, addr.Hex(), l[0].Address)
+	require.Equal(t, "127.0.0.1:8547", l[0].Host)
+
+	require.Error(t, l.Set("127.0.0.1:8547"))
+	require.Error(t, l.Set("0x1234@127.0.0.1:8547"))
+}
+
+func TestParseChannelID(t *testing.T) {
+	id, err := parseChannelID("0x" + common.Bytes2Hex(make([]byte, 32)))
+	require.NoError(t, err)
+	require.Zero(t, id)
+
+	_, err = parseChannelID("0x1234")
+	require.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/client"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"github.com/perun-network/perun-credential-payment/client/credstore"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+)
+
+func holderConnect(ctx context.Context, args []string) error {
+	var (
+		cf      clientFlags
+		issuer  string
+		deposit string
+		token   string
+	)
+	fs := flag.NewFlagSet("holder connect", flag.ExitOnError)
+	cf.register(fs)
+	fs.StringVar(&issuer, "issuer", "", "address of the issuer, which must be a configured peer")
+	fs.StringVar(&deposit, "deposit", "", "amount deposited into the connection")
+	fs.StringVar(&token, "token", "", "token to deposit (default: ETH)")
+	fs.Parse(args)
+
+	issuerAddr, err := parseAddress("issuer", issuer)
+	if err != nil {
+		return err
+	}
+	amount, err := parseEth(deposit)
+	if err != nil {
+		return err
+	}
+
+	c, err := cf.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Shutdown()
+
+	ah, err := asset(c, token)
+	if err != nil {
+		return err
+	}
+	conn, err := c.Connect(ctx, ethwallet.AsWalletAddr(issuerAddr), client.Funding{Asset: ah, Balance: amount})
+	if err != nil {
+		return fmt.Errorf("connecting: %w", err)
+	}
+	fmt.Printf("%x\n", conn.ID())
+	return nil
+}
+
+func holderRequest(ctx context.Context, args []string) error {
+	var (
+		cf      clientFlags
+		channel string
+		doc     string
+		price   string
+		token   string
+		store   string
+		out     string
+	)
+	fs := flag.NewFlagSet("holder request", flag.ExitOnError)
+	cf.register(fs)
+	fs.StringVar(&channel, "channel", "", "ID of the connection")
+	fs.StringVar(&doc, "doc", "", "file containing the document")
+	fs.StringVar(&price, "price", "", "offered price")
+	fs.StringVar(&token, "token", "", "token to pay in (default: ETH)")
+	fs.StringVar(&store, "store", "credpay-credentials", "directory of the credential store")
+	fs.StringVar(&out, "out", "", "file to write the credential presentation to (default: stdout)")
+	fs.Parse(args)
+
+	document, err := os.ReadFile(doc)
+	if err != nil {
+		return fmt.Errorf("reading document: %w", err)
+	}
+	amount, err := parseEth(price)
+	if err != nil {
+		return err
+	}
+	s, err := credstore.Open(store)
+	if err != nil {
+		return fmt.Errorf("opening credential store: %w", err)
+	}
+	defer s.Close()
+
+	c, err := cf.start(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Shutdown()
+
+	conn, err := findConnection(c, channel)
+	if err != nil {
+		return err
+	}
+	ah, err := asset(c, token)
+	if err != nil {
+		return err
+	}
+	issuer := peerOf(conn)
+
+	// Quotes of the issuer are not accepted by this command.
+	quoteCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go drainQuotes(quoteCtx, conn)
+
+	async, err := conn.RequestCredential(ctx, document, ah, amount, issuer)
+	if err != nil {
+		return fmt.Errorf("requesting credential: %w", err)
+	}
+	resp, err := async.Await(ctx)
+	if err != nil {
+		return fmt.Errorf("awaiting credential: %w", err)
+	}
+	cred := resp.Credential(document, nil)
+	if err := cred.Verify(issuer, c.Address()); err != nil {
+		return fmt.Errorf("verifying credential: %w", err)
+	}
+	if err := resp.Accept(ctx); err != nil {
+		return fmt.Errorf("paying for credential: %w", err)
+	}
+
+	if err := s.Add(&credstore.Record{Credential: cred, OfferID: async.ID(), Price: amount, ReceivedAt: time.Now()}); err != nil {
+		return fmt.Errorf("storing credential: %w", err)
+	}
+	p, err := credstore.MarshalPresentation(cred)
+	if err != nil {
+		return fmt.Errorf("encoding credential: %w", err)
+	}
+	if out == "" {
+		_, err = fmt.Printf("%s\n", p)
+		return err
+	}
+	return os.WriteFile(out, p, 0o600)
+}
+
+// drainQuotes discards the quotes received on the connection until the
+// context is done.
+func drainQuotes(ctx context.Context, conn *connection.Connection) {
+	for {
+		q, err := conn.NextQuote(ctx)
+		if err != nil {
+			return
+		}
+		log.Printf("Ignoring quote of %s on connection %x", formatEth(q.TotalPrice()), conn.ID())
+	}
+}
+
+// peerOf returns the address of the peer of the connection.
+func peerOf(conn *connection.Connection) common.Address {
+	parts := conn.Params().Parts
+	return ethwallet.AsEthAddr(parts[1-conn.Idx()])
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/client"
+	"github.com/perun-network/perun-credential-payment/client/connection"
+	"github.com/perun-network/perun-credential-payment/client/ledger"
+)
+
+func issuerServe(ctx context.Context, args []string) error {
+	var (
+		cf       clientFlags
+		docs     stringList
+		price    string
+		ledgerDB string
+	)
+	fs := flag.NewFlagSet("issuer serve", flag.ExitOnError)
+	cf.register(fs)
+	fs.Var(&docs, "doc", "`file` containing a document that may be issued (repeatable)")
+	fs.StringVar(&price, "price", "0", "minimum price per document")
+	fs.StringVar(&ledgerDB, "ledger", "", "directory of the issuance ledger (default: none)")
+	fs.Parse(args)
+
+	if len(docs) == 0 {
+		return errors.New("no documents: set -doc")
+	}
+	is := &issuer{catalog: make(map[app.Hash]string)}
+	for _, doc := range docs {
+		b, err := os.ReadFile(doc)
+		if err != nil {
+			return fmt.Errorf("reading document: %w", err)
+		}
+		is.catalog[app.ComputeDocumentHash(b)] = doc
+	}
+	var err error
+	if is.price, err = parseEth(price); err != nil {
+		return err
+	}
+	if ledgerDB != "" {
+		if is.ledger, err = ledger.Open(ledgerDB); err != nil {
+			return fmt.Errorf("opening ledger: %w", err)
+		}
+		defer is.ledger.Close()
+	}
+
+	if is.client, err = cf.start(ctx); err != nil {
+		return err
+	}
+	defer is.client.Shutdown()
+	log.Printf("Serving as issuer %v", is.client.Address())
+
+	for _, conn := range is.client.RestoredConnections() {
+		go is.serve(ctx, conn)
+		for _, req := range conn.RestoredCredentialRequests() {
+			go is.handle(ctx, conn, req)
+		}
+	}
+	go func() {
+		for {
+			req, err := is.client.NextConnectionRequest(ctx)
+			if err != nil {
+				log.Printf("Awaiting connection request: %v", err)
+				return
+			}
+			conn, err := req.Accept(ctx)
+			if err != nil {
+				log.Printf("Accepting connection from %v: %v", req.Peer(), err)
+				continue
+			}
+			log.Printf("Accepted connection %x from %v", conn.ID(), req.Peer())
+			go is.serve(ctx, conn)
+		}
+	}()
+
+	<-ctx.Done()
+	return nil
+}
+
+// issuer issues credentials for the documents in its catalog.
+type issuer struct {
+	client  *client.Client
+	catalog map[app.Hash]string // Document hash to file name.
+	price   *big.Int
+	ledger  *ledger.Ledger
+}
+
+// serve handles the credential requests of the connection and settles the
+// connection once it is finalized.
+func (is *issuer) serve(ctx context.Context, conn *connection.Connection) {
+	if is.ledger != nil {
+		conn.SetIssuanceLog(is.ledger)
+	}
+	go func() {
+		if err := conn.WaitConcludadable(ctx); err != nil {
+			return
+		}
+		if err := conn.Close(ctx); err != nil {
+			log.Printf("Closing connection %x: %v", conn.ID(), err)
+			return
+		}
+		log.Printf("Settled connection %x", conn.ID())
+	}()
+
+	for {
+		req, err := conn.NextCredentialRequest(ctx)
+		if err != nil {
+			return
+		}
+		go is.handle(ctx, conn, req)
+	}
+}
+
+// handle issues the requested credentials if all documents are in the
+// catalog and the offered prices are high enough, and rejects the request
+// otherwise.
+func (is *issuer) handle(ctx context.Context, conn *connection.Connection, req *connection.CredentialRequest) {
+	if reason := is.check(req); reason != "" {
+		log.Printf("Rejecting request on connection %x: %s", conn.ID(), reason)
+		if err := req.Reject(ctx, reason); err != nil {
+			log.Printf("Rejecting request: %v", err)
+		}
+		return
+	}
+	if err := req.IssueCredential(ctx, is.client.Signer()); err != nil {
+		log.Printf("Issuing credential on connection %x: %v", conn.ID(), err)
+		return
+	}
+	log.Printf("Issued credential on connection %x", conn.ID())
+}
+
+// check returns why the request cannot be served, or the empty string if it
+// can. We only serve requests that name us as an issuer.
+func (is *issuer) check(req *connection.CredentialRequest) string {
+	issuer, _ := app.IssuerOf(is.client.Signer())
+	issuers, _ := req.Issuers()
+	named := false
+	for _, a := range issuers {
+		named = named || a == issuer
+	}
+	if !named {
+		return fmt.Sprintf("not an issuer of the request: %v", issuer)
+	}
+
+	prices := req.Prices()
+	for i, h := range req.DataHashes() {
+		if _, ok := is.catalog[h]; !ok {
+			return fmt.Sprintf("unknown document: %x", h)
+		}
+		if prices[i].Cmp(is.price) < 0 {
+			return fmt.Sprintf("price too low: %s < %s", formatEth(prices[i]), formatEth(is.price))
+		}
+	}
+	return ""
+}
+
+// stringList is a flag.Value holding repeated string flags.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
@@ -0,0 +1,69 @@
+// Command credpay runs the credential payment protocol as a holder or an
+// issuer.
+//
+// Connections are persisted in the database given by -db, so that they
+// survive between invocations. Only one invocation may use a database at a
+// time.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+const usage = `Usage: credpay <command> [flags]
+
+Commands:
+  issuer serve     accept connections and issue credentials for known documents
+  holder connect   open a connection to an issuer
+  holder request   request a credential over a connection
+  channels list    list the open connections
+  channel close    close a connection and withdraw the funds
+  balance          show the on-chain and channel balances
+  deploy           deploy the contracts and write their addresses to a manifest
+  daemon           serve the client over a local HTTP/JSON API
+
+Run "credpay <command> -h" for the flags of a command.
+`
+
+type command struct {
+	name string
+	run  func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"issuer serve", issuerServe},
+	{"holder connect", holderConnect},
+	{"holder request", holderRequest},
+	{"channels list", channelsList},
+	{"channel close", channelClose},
+	{"balance", balance},
+	{"deploy", deployContracts},
+	{"daemon", runDaemon},
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx, os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "credpay:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	for _, c := range commands {
+		words := strings.Fields(c.name)
+		if len(args) >= len(words) && strings.Join(args[:len(words)], " ") == c.name {
+			return c.run(ctx, args[len(words):])
+		}
+	}
+	fmt.Fprint(os.Stderr, usage)
+	return errors.New("unknown command")
+}
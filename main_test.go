@@ -2,11 +2,13 @@ package main_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/perun-network/perun-credential-payment/app"
 	"github.com/perun-network/perun-credential-payment/client"
 	"github.com/perun-network/perun-credential-payment/client/connection"
@@ -16,14 +18,65 @@ import (
 
 func TestCredentialSwap(t *testing.T) {
 	t.Run("Honest holder", func(t *testing.T) {
-		runCredentialSwapTest(t, true)
+		runCredentialSwapTest(t, swapTest{honestHolder: true, asset: ethAsset})
 	})
 	t.Run("Dishonest holder", func(t *testing.T) {
-		runCredentialSwapTest(t, false)
+		runCredentialSwapTest(t, swapTest{honestHolder: false, asset: ethAsset})
+	})
+	t.Run("Honest holder ERC20", func(t *testing.T) {
+		runCredentialSwapTest(t, swapTest{honestHolder: true, asset: erc20Asset})
+	})
+	t.Run("Dishonest holder ERC20", func(t *testing.T) {
+		runCredentialSwapTest(t, swapTest{honestHolder: false, asset: erc20Asset})
+	})
+	t.Run("Issuer rejects", func(t *testing.T) {
+		runCredentialSwapTest(t, swapTest{honestHolder: true, asset: ethAsset, underpay: true})
+	})
+	t.Run("Issuer quotes", func(t *testing.T) {
+		runCredentialSwapTest(t, swapTest{honestHolder: true, asset: ethAsset, quote: true})
+	})
+	t.Run("EIP-712", func(t *testing.T) {
+		runCredentialSwapTest(t, swapTest{honestHolder: true, asset: ethAsset, scheme: app.SchemeEIP712})
+	})
+	t.Run("Dishonest holder EIP-712", func(t *testing.T) {
+		runCredentialSwapTest(t, swapTest{honestHolder: false, asset: ethAsset, scheme: app.SchemeEIP712})
+	})
+	t.Run("Dishonest holder salted", func(t *testing.T) {
+		runCredentialSwapTest(t, swapTest{honestHolder: false, asset: ethAsset, salted: true})
+	})
+	t.Run("Issuer quotes salted", func(t *testing.T) {
+		runCredentialSwapTest(t, swapTest{honestHolder: true, asset: ethAsset, quote: true, salted: true})
 	})
 }
 
-func runCredentialSwapTest(t *testing.T, honestHolder bool) {
+type swapTest struct {
+	honestHolder bool
+	asset        assetSelector
+	// underpay determines whether the holder offers less than the price
+	// expected by the issuer, in which case the issuer rejects the request.
+	underpay bool
+	// quote determines whether the issuer sends a quote that is accepted by
+	// the holder instead of the holder requesting the credential.
+	quote bool
+	// scheme is the signature scheme that the holder requests.
+	scheme app.SigScheme
+	// salted determines whether the document is committed to with a salt,
+	// which both parties know.
+	salted bool
+}
+
+// assetSelector selects the asset that is used for payment.
+type assetSelector func(env *test.Environment) common.Address
+
+func ethAsset(env *test.Environment) common.Address {
+	return env.Contracts.AssetHolder
+}
+
+func erc20Asset(env *test.Environment) common.Address {
+	return env.Contracts.AssetHolderERC20
+}
+
+func runCredentialSwapTest(t *testing.T, tc swapTest) {
 	require := require.New(t)
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(cancel)
@@ -34,10 +87,21 @@ func runCredentialSwapTest(t *testing.T, honestHolder bool) {
 	wg, errs := sync.WaitGroup{}, make(chan error)
 	wg.Add(2)
 	holder, issuer := env.Holder, env.Issuer
+	asset := tc.asset(env)
 
 	doc := []byte("Perun/Bosch: SSI Credential Payment")
+	var salt []byte
+	if tc.salted {
+		var err error
+		salt, err = app.NewSalt()
+		require.NoError(err)
+	}
 	balance := test.EthToWei(big.NewFloat(5))
 	price := test.EthToWei(big.NewFloat(1))
+	offeredPrice := price
+	if tc.underpay {
+		offeredPrice = test.EthToWei(big.NewFloat(0.5))
+	}
 
 	// Run credential holder.
 	go func() {
@@ -45,10 +109,15 @@ func runCredentialSwapTest(t *testing.T, honestHolder bool) {
 			ctx,
 			holder,
 			issuer,
+			asset,
 			balance,
 			doc,
-			price,
-			honestHolder,
+			salt,
+			offeredPrice,
+			tc.honestHolder,
+			tc.underpay,
+			tc.quote,
+			tc.scheme,
 		)
 		if err != nil {
 			errs <- fmt.Errorf("running credential holder: %w", err)
@@ -64,8 +133,11 @@ func runCredentialSwapTest(t *testing.T, honestHolder bool) {
 			ctx,
 			issuer,
 			holder,
+			asset,
 			doc,
+			salt,
 			price,
+			tc.quote,
 		)
 		if err != nil {
 			errs <- fmt.Errorf("running credential issuer: %w", err)
@@ -100,34 +172,46 @@ func runCredentialHolder(
 	ctx context.Context,
 	holder *client.Client,
 	issuer *client.Client,
+	asset common.Address,
 	balance *big.Int,
 	doc []byte,
+	salt []byte,
 	price *big.Int,
 	honest bool,
+	expectRejection bool,
+	quote bool,
+	scheme app.SigScheme,
 ) error {
 	// Connect.
-	conn, err := holder.Connect(ctx, issuer.PerunAddress(), balance)
+	funding := client.Funding{Asset: asset, Balance: balance}
+	conn, err := holder.Connect(ctx, issuer.PerunAddress(), funding)
 	if err != nil {
 		return fmt.Errorf("proposing connection: %w", err)
 	}
+	conn.SetSignatureScheme(scheme)
 
 	// Buy credential.
 	{
-		// Request credential.
-		asyncCred, err := conn.RequestCredential(ctx, doc, price, issuer.Address())
-		if err != nil {
-			return fmt.Errorf("requesting credential: %w", err)
+		// Request credential and wait for the transaction issueing the
+		// credential.
+		var resp *connection.CredentialsProposal
+		if quote {
+			resp, err = acceptQuote(ctx, conn, doc, salt, price)
+		} else {
+			resp, err = requestCredential(ctx, conn, doc, salt, asset, price, issuer.Address())
 		}
-
-		// Wait for the transaction issueing the credential.
-		resp, err := asyncCred.Await(ctx)
-		if err != nil {
+		if rejected := (*connection.RequestRejectedError)(nil); errors.As(err, &rejected) && expectRejection {
+			holder.Logf("Credential request rejected: %v", rejected.Reason)
+			return closeConnection(ctx, conn)
+		} else if err != nil {
 			return fmt.Errorf("awaiting credential: %w", err)
+		} else if expectRejection {
+			return fmt.Errorf("expected credential request to be rejected")
 		}
 
-		cred := app.Credential{
-			Document:  doc,
-			Signature: resp.Signature,
+		cred := resp.Credential(0, doc, salt)
+		if err := cred.Verify(issuer.Address(), holder.Address()); err != nil {
+			return fmt.Errorf("verifying credential: %w", err)
 		}
 		holder.Logf("Obtained credential: %v", cred.String())
 
@@ -152,12 +236,57 @@ func runCredentialHolder(
 		}
 	}
 
-	// Close connection.
-	err = conn.Close(ctx)
+	return closeConnection(ctx, conn)
+}
+
+func requestCredential(
+	ctx context.Context,
+	conn *connection.Connection,
+	doc []byte,
+	salt []byte,
+	asset common.Address,
+	price *big.Int,
+	issuer common.Address,
+) (*connection.CredentialsProposal, error) {
+	docs, salts := [][]byte{doc}, [][]byte{salt}
+	asyncCreds, err := conn.RequestSaltedCredentials(ctx, docs, salts, asset, []*big.Int{price}, issuer)
 	if err != nil {
-		return fmt.Errorf("closing connection: %w", err)
+		return nil, fmt.Errorf("requesting credential: %w", err)
 	}
+	return asyncCreds.Await(ctx)
+}
 
+func acceptQuote(
+	ctx context.Context,
+	conn *connection.Connection,
+	doc []byte,
+	salt []byte,
+	price *big.Int,
+) (*connection.CredentialsProposal, error) {
+	q, err := conn.NextQuote(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("awaiting quote: %w", err)
+	}
+
+	// Check quote.
+	if hs := q.DataHashes(); len(hs) != 1 || hs[0] != app.ComputeSaltedDocumentHash(doc, salt) {
+		return nil, fmt.Errorf("wrong document")
+	} else if q.TotalPrice().Cmp(price) != 0 {
+		return nil, fmt.Errorf("wrong price")
+	}
+
+	asyncCreds, err := conn.AcceptQuote(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("accepting quote: %w", err)
+	}
+	return asyncCreds.Await(ctx)
+}
+
+func closeConnection(ctx context.Context, conn *connection.Connection) error {
+	err := conn.Close(ctx)
+	if err != nil {
+		return fmt.Errorf("closing connection: %w", err)
+	}
 	return nil
 }
 
@@ -165,8 +294,11 @@ func runCredentialIssuer(
 	ctx context.Context,
 	issuer *client.Client,
 	holder *client.Client,
+	asset common.Address,
 	doc []byte,
+	salt []byte,
 	price *big.Int,
+	quote bool,
 ) error {
 	// Connect.
 	conn, err := func() (*connection.Connection, error) {
@@ -195,21 +327,37 @@ func runCredentialIssuer(
 
 	// Issue credential.
 	err = func() error {
+		// Send quote.
+		if quote {
+			docs, salts := [][]byte{doc}, [][]byte{salt}
+			err := conn.SendSaltedQuote(ctx, docs, salts, asset, []*big.Int{price}, issuer.Signer())
+			if err != nil {
+				return fmt.Errorf("sending quote: %w", err)
+			}
+		}
+
 		// Read next credential request.
 		req, err := conn.NextCredentialRequest(ctx)
 		if err != nil {
 			return fmt.Errorf("awaiting next credential request: %w", err)
 		}
 
-		// Check document and price.
-		if err := req.CheckDoc(doc); err != nil {
+		// Check document and price. Reject the request if the price does
+		// not match.
+		if err := req.CheckDoc(doc, salt); err != nil {
 			return fmt.Errorf("checking document: %w", err)
 		} else if err := req.CheckPrice(price); err != nil {
-			return fmt.Errorf("checking price: %w", err)
+			err := req.Reject(ctx, err.Error())
+			if err != nil {
+				return fmt.Errorf("rejecting credential request: %w", err)
+			}
+			return nil
+		} else if quote && !req.Quoted() {
+			return fmt.Errorf("expected quoted credential request")
 		}
 
 		// Issue credential.
-		err = req.IssueCredential(ctx, issuer.Account())
+		err = req.IssueCredential(ctx, issuer.Signer())
 		if err != nil {
 			return fmt.Errorf("issueing credential: %w", err)
 		}
@@ -226,11 +374,170 @@ func runCredentialIssuer(
 		return fmt.Errorf("waiting for channel finalization: %w", err)
 	}
 
-	// Close connection.
-	err = conn.Close(ctx)
-	if err != nil {
-		return fmt.Errorf("closing connection: %w", err)
+	return closeConnection(ctx, conn)
+}
+
+func TestConcurrentCredentialRequests(t *testing.T) {
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// Setup test environment.
+	env := test.Setup(t)
+	holder, issuer := env.Holder, env.Issuer
+	asset := ethAsset(env)
+	docs := [][]byte{[]byte("Document 1"), []byte("Document 2")}
+	balance := test.EthToWei(big.NewFloat(5))
+	price := test.EthToWei(big.NewFloat(1))
+
+	// Run credential issuer. The requests are answered in reverse order.
+	errs := make(chan error, 1)
+	go func() {
+		errs <- func() error {
+			req, err := issuer.NextConnectionRequest(ctx)
+			if err != nil {
+				return fmt.Errorf("awaiting next connection request: %w", err)
+			}
+			conn, err := req.Accept(ctx)
+			if err != nil {
+				return fmt.Errorf("accepting connection request: %w", err)
+			}
+
+			var reqs []*connection.CredentialRequest
+			for range docs {
+				req, err := conn.NextCredentialRequest(ctx)
+				if err != nil {
+					return fmt.Errorf("awaiting next credential request: %w", err)
+				}
+				reqs = append(reqs, req)
+			}
+			for i := len(reqs) - 1; i >= 0; i-- {
+				if err := reqs[i].CheckDoc(docs[i], nil); err != nil {
+					return fmt.Errorf("checking document: %w", err)
+				}
+				err := reqs[i].IssueCredential(ctx, issuer.Signer())
+				if err != nil {
+					return fmt.Errorf("issueing credential: %w", err)
+				}
+			}
+
+			err = conn.WaitConcludadable(ctx)
+			if err != nil {
+				return fmt.Errorf("waiting for channel finalization: %w", err)
+			}
+			return closeConnection(ctx, conn)
+		}()
+	}()
+
+	// Connect and request both credentials before awaiting them.
+	conn, err := holder.Connect(ctx, issuer.PerunAddress(), client.Funding{Asset: asset, Balance: balance})
+	require.NoError(err)
+	var asyncCreds []*connection.AsyncCredential
+	for _, doc := range docs {
+		asyncCred, err := conn.RequestCredential(ctx, doc, asset, price, issuer.Address())
+		require.NoError(err)
+		asyncCreds = append(asyncCreds, asyncCred)
 	}
+	require.NotEqual(asyncCreds[0].ID(), asyncCreds[1].ID())
 
-	return nil
+	for i := len(asyncCreds) - 1; i >= 0; i-- {
+		resp, err := asyncCreds[i].Await(ctx)
+		require.NoError(err)
+		require.NoError(resp.Credential(docs[i], nil).Verify(issuer.Address(), holder.Address()))
+		require.NoError(resp.Accept(ctx))
+	}
+	require.NoError(closeConnection(ctx, conn))
+	require.NoError(<-errs)
+
+	// Settled connections are no longer listed.
+	require.Empty(holder.Connections())
+	require.Empty(issuer.Connections())
+}
+
+func TestRestoreConnection(t *testing.T) {
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// Setup test environment.
+	env := test.Setup(t)
+	issuer := env.Issuer
+	asset := ethAsset(env)
+	doc := []byte("Perun/Bosch: SSI Credential Payment")
+	balance := test.EthToWei(big.NewFloat(5))
+	price := test.EthToWei(big.NewFloat(1))
+
+	// Run credential issuer. The request is answered after the holder
+	// restarted.
+	received, restarted := make(chan struct{}), make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- func() error {
+			req, err := issuer.NextConnectionRequest(ctx)
+			if err != nil {
+				return fmt.Errorf("awaiting next connection request: %w", err)
+			}
+			conn, err := req.Accept(ctx)
+			if err != nil {
+				return fmt.Errorf("accepting connection request: %w", err)
+			}
+
+			credReq, err := conn.NextCredentialRequest(ctx)
+			if err != nil {
+				return fmt.Errorf("awaiting next credential request: %w", err)
+			}
+			close(received)
+			select {
+			case <-restarted:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if err := credReq.CheckDoc(doc, nil); err != nil {
+				return fmt.Errorf("checking document: %w", err)
+			}
+			err = credReq.IssueCredential(ctx, issuer.Signer())
+			if err != nil {
+				return fmt.Errorf("issueing credential: %w", err)
+			}
+
+			err = conn.WaitConcludadable(ctx)
+			if err != nil {
+				return fmt.Errorf("waiting for channel finalization: %w", err)
+			}
+			return closeConnection(ctx, conn)
+		}()
+	}()
+
+	// Connect and request the credential.
+	conn, err := env.Holder.Connect(ctx, issuer.PerunAddress(), client.Funding{Asset: asset, Balance: balance})
+	require.NoError(err)
+	_, err = conn.RequestSaltedCredentials(ctx, [][]byte{doc}, [][]byte{nil}, asset, []*big.Int{price}, issuer.Address())
+	require.NoError(err)
+	select {
+	case <-received:
+	case err := <-errs:
+		require.NoError(err)
+	}
+
+	// Restart the holder and resume the request on the restored connection.
+	env.RestartHolder(ctx, t)
+	holder := env.Holder
+	restored := holder.RestoredConnections()
+	require.Len(restored, 1)
+	conn = restored[0]
+	reqs := conn.RestoredRequests()
+	require.Len(reqs, 1)
+	close(restarted)
+
+	resp, err := reqs[0].Await(ctx)
+	require.NoError(err)
+	require.NoError(resp.Credential(0, doc, nil).Verify(issuer.Address(), holder.Address()))
+	require.NoError(resp.Accept(ctx))
+	require.NoError(closeConnection(ctx, conn))
+	require.NoError(<-errs)
+
+	// Settled connections are no longer listed.
+	require.Empty(holder.Connections())
+	require.Empty(issuer.Connections())
 }
@@ -0,0 +1,187 @@
+// Package deploy deploys the contracts of the credential payment protocol and
+// records their addresses in a manifest file.
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/perun-network/perun-credential-payment/app"
+	ethchannel "perun.network/go-perun/backend/ethereum/channel"
+)
+
+// Manifest records the addresses of the deployed contracts.
+type Manifest struct {
+	ChainID     uint64         `json:"chainID"`
+	Adjudicator common.Address `json:"adjudicator"`
+	AssetHolder common.Address `json:"assetHolder"`
+	App         common.Address `json:"app"`
+	ERC20       []ERC20        `json:"erc20,omitempty"`
+}
+
+// ERC20 is an ERC20 asset holder and the token it holds.
+type ERC20 struct {
+	AssetHolder common.Address `json:"assetHolder"`
+	Token       common.Address `json:"token"`
+}
+
+// ReadManifest reads the manifest file at the given path.
+func ReadManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	m := new(Manifest)
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Write writes the manifest to the file at the given path.
+func (m *Manifest) Write(path string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Options configure which optional contracts are deployed.
+type Options struct {
+	// Tokens are the tokens for which ERC20 asset holders are deployed,
+	// unless the manifest already contains an asset holder for them.
+	Tokens []common.Address
+	// TestToken, if not nil, deploys a PerunToken and an asset holder for it.
+	TestToken *TestToken
+}
+
+// TestToken configures the deployment of a PerunToken.
+type TestToken struct {
+	Holders []common.Address
+	Balance *big.Int
+}
+
+// Deploy deploys the contracts that are missing from the given manifest and
+// verifies the bytecode of all contracts. Contracts in the manifest are
+// reused. The returned manifest contains the addresses of all contracts. The
+// given manifest may be nil.
+func Deploy(ctx context.Context, d *Deployer, existing *Manifest, opts Options) (*Manifest, error) {
+	m := &Manifest{ChainID: d.chainID.Uint64()}
+	if existing != nil {
+		if existing.ChainID != 0 && existing.ChainID != m.ChainID {
+			return nil, fmt.Errorf("manifest is for chain %d, not %d", existing.ChainID, m.ChainID)
+		}
+		*m = *existing
+		m.ChainID = d.chainID.Uint64()
+		m.ERC20 = append([]ERC20(nil), existing.ERC20...)
+	}
+
+	var txs []*types.Transaction
+	wait := func() error {
+		err := d.WaitDeployment(ctx, txs...)
+		txs = nil
+		return err
+	}
+	deploy := func(addr *common.Address, name string, fn func() (common.Address, *types.Transaction, error)) error {
+		if *addr != (common.Address{}) {
+			return nil
+		}
+		a, tx, err := fn()
+		if err != nil {
+			return fmt.Errorf("deploying %s: %w", name, err)
+		}
+		*addr, txs = a, append(txs, tx)
+		return nil
+	}
+
+	// The asset holders depend on the adjudicator.
+	if err := deploy(&m.Adjudicator, "adjudicator", func() (common.Address, *types.Transaction, error) {
+		return d.DeployAdjudicator(ctx)
+	}); err != nil {
+		return nil, err
+	}
+	if err := deploy(&m.App, "app", func() (common.Address, *types.Transaction, error) {
+		return d.DeployApp(ctx)
+	}); err != nil {
+		return nil, err
+	}
+	if err := wait(); err != nil {
+		return nil, err
+	}
+
+	if err := deploy(&m.AssetHolder, "ETH asset holder", func() (common.Address, *types.Transaction, error) {
+		return d.DeployAssetHolderETH(ctx, m.Adjudicator)
+	}); err != nil {
+		return nil, err
+	}
+	tokens := opts.Tokens
+	if opts.TestToken != nil {
+		var token common.Address
+		if err := deploy(&token, "PerunToken", func() (common.Address, *types.Transaction, error) {
+			return d.DeployPerunToken(ctx, opts.TestToken.Holders, opts.TestToken.Balance)
+		}); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	if err := wait(); err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		if _, ok := m.assetHolderERC20(token); ok {
+			continue
+		}
+		e := ERC20{Token: token}
+		if err := deploy(&e.AssetHolder, fmt.Sprintf("ERC20 asset holder for %v", token), func() (common.Address, *types.Transaction, error) {
+			return d.DeployAssetHolderERC20(ctx, m.Adjudicator, token)
+		}); err != nil {
+			return nil, err
+		}
+		m.ERC20 = append(m.ERC20, e)
+	}
+	if err := wait(); err != nil {
+		return nil, err
+	}
+
+	if err := Verify(ctx, d, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Verify checks that the contracts of the manifest are deployed with the
+// expected bytecode.
+func Verify(ctx context.Context, backend bind.ContractBackend, m *Manifest) error {
+	if err := ethchannel.ValidateAdjudicator(ctx, backend, m.Adjudicator); err != nil {
+		return fmt.Errorf("validating adjudicator: %w", err)
+	}
+	if err := app.ValidateCredentialSwap(ctx, backend, m.App); err != nil {
+		return fmt.Errorf("validating app: %w", err)
+	}
+	if err := ethchannel.ValidateAssetHolderETH(ctx, backend, m.AssetHolder, m.Adjudicator); err != nil {
+		return fmt.Errorf("validating ETH asset holder: %w", err)
+	}
+	for _, e := range m.ERC20 {
+		if err := ethchannel.ValidateAssetHolderERC20(ctx, backend, e.AssetHolder, m.Adjudicator, e.Token); err != nil {
+			return fmt.Errorf("validating ERC20 asset holder %v: %w", e.AssetHolder, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) assetHolderERC20(token common.Address) (common.Address, bool) {
+	for _, e := range m.ERC20 {
+		if e.Token == token {
+			return e.AssetHolder, true
+		}
+	}
+	return common.Address{}, false
+}
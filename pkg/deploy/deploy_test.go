@@ -0,0 +1,31 @@
+package deploy_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/pkg/deploy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest_WriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contracts.json")
+	m := &deploy.Manifest{
+		ChainID:     1337,
+		Adjudicator: common.HexToAddress("0x01"),
+		AssetHolder: common.HexToAddress("0x02"),
+		App:         common.HexToAddress("0x03"),
+		ERC20:       []deploy.ERC20{{AssetHolder: common.HexToAddress("0x04"), Token: common.HexToAddress("0x05")}},
+	}
+	require.NoError(t, m.Write(path))
+
+	read, err := deploy.ReadManifest(path)
+	require.NoError(t, err)
+	require.Equal(t, m, read)
+}
+
+func TestReadManifest_Missing(t *testing.T) {
+	_, err := deploy.ReadManifest(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
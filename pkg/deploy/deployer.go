@@ -0,0 +1,128 @@
+package deploy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/pkg/errors"
+
+	"perun.network/go-perun/backend/ethereum/bindings/adjudicator"
+	"perun.network/go-perun/backend/ethereum/bindings/assetholdererc20"
+	"perun.network/go-perun/backend/ethereum/bindings/assetholdereth"
+	"perun.network/go-perun/backend/ethereum/bindings/peruntoken"
+)
+
+// Deployer sends contract deployment transactions from a single account.
+type Deployer struct {
+	*ethclient.Client
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+	nonce   uint64
+}
+
+// NewDeployer connects to the node at the given URL and returns a deployer
+// that signs with the given key.
+func NewDeployer(ctx context.Context, nodeURL string, key *ecdsa.PrivateKey, chainID *big.Int) (*Deployer, error) {
+	client, err := ethclient.DialContext(ctx, nodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("dialing: %w", err)
+	}
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := client.NonceAt(ctx, addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting nonce: %w", err)
+	}
+
+	return &Deployer{
+		Client:  client,
+		key:     key,
+		chainID: chainID,
+		nonce:   nonce,
+	}, nil
+}
+
+// ChainID returns the chain ID with which transactions are signed.
+func (c *Deployer) ChainID() *big.Int {
+	return new(big.Int).Set(c.chainID)
+}
+
+func (c *Deployer) DeployAdjudicator(ctx context.Context) (addr common.Address, tx *types.Transaction, err error) {
+	return c.deployContract(ctx, func(to *bind.TransactOpts, c *ethclient.Client) (addr common.Address, tx *types.Transaction, err error) {
+		addr, tx, _, err = adjudicator.DeployAdjudicator(to, c)
+		return
+	})
+}
+
+func (c *Deployer) DeployApp(ctx context.Context) (addr common.Address, tx *types.Transaction, err error) {
+	return c.deployContract(ctx, func(to *bind.TransactOpts, c *ethclient.Client) (addr common.Address, tx *types.Transaction, err error) {
+		addr, tx, _, err = app.DeployCredentialSwap(to, c)
+		return
+	})
+}
+
+func (c *Deployer) DeployAssetHolderETH(ctx context.Context, adjudicatorAddr common.Address) (addr common.Address, tx *types.Transaction, err error) {
+	return c.deployContract(ctx, func(to *bind.TransactOpts, c *ethclient.Client) (addr common.Address, tx *types.Transaction, err error) {
+		addr, tx, _, err = assetholdereth.DeployAssetHolderETH(to, c, adjudicatorAddr)
+		return
+	})
+}
+
+func (c *Deployer) DeployPerunToken(ctx context.Context, initAccs []common.Address, initBal *big.Int) (addr common.Address, tx *types.Transaction, err error) {
+	return c.deployContract(ctx, func(to *bind.TransactOpts, c *ethclient.Client) (addr common.Address, tx *types.Transaction, err error) {
+		addr, tx, _, err = peruntoken.DeployPerunToken(to, c, initAccs, initBal)
+		return
+	})
+}
+
+func (c *Deployer) DeployAssetHolderERC20(ctx context.Context, adjudicatorAddr common.Address, tokenAddr common.Address) (addr common.Address, tx *types.Transaction, err error) {
+	return c.deployContract(ctx, func(to *bind.TransactOpts, c *ethclient.Client) (addr common.Address, tx *types.Transaction, err error) {
+		addr, tx, _, err = assetholdererc20.DeployAssetHolderERC20(to, c, adjudicatorAddr, tokenAddr)
+		return
+	})
+}
+
+func (c *Deployer) deployContract(
+	ctx context.Context,
+	deployContract func(*bind.TransactOpts, *ethclient.Client) (common.Address, *types.Transaction, error),
+) (common.Address, *types.Transaction, error) {
+	tr, err := c.newTransactor(ctx)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	addr, tx, err := deployContract(tr, c.Client)
+	if err != nil {
+		return common.Address{}, nil, errors.WithMessage(err, "sending deployment transaction")
+	}
+	return addr, tx, nil
+}
+
+// WaitDeployment waits until the given deployment transactions are mined.
+func (c *Deployer) WaitDeployment(ctx context.Context, txs ...*types.Transaction) (err error) {
+	for _, tx := range txs {
+		_, err = bind.WaitDeployed(ctx, c.Client, tx)
+		if err != nil {
+			return errors.WithMessagef(err, "waiting for deployment: %v", tx.Hash())
+		}
+	}
+	return nil
+}
+
+func (c *Deployer) newTransactor(ctx context.Context) (*bind.TransactOpts, error) {
+	tr, err := bind.NewKeyedTransactorWithChainID(c.key, c.chainID)
+	if err != nil {
+		return nil, err
+	}
+	tr.Context = ctx
+	tr.Nonce = new(big.Int).SetUint64(c.nonce)
+	c.nonce++
+	return tr, nil
+}
@@ -0,0 +1,165 @@
+package remotesigner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+)
+
+// DefaultTimeout is the time a Signer waits for the answer to a request.
+const DefaultTimeout = 10 * time.Second
+
+// Signer is an app.Signer that forwards signing requests to a Server. After
+// a failed request, the connection is closed and a new connection is dialed
+// for the next request.
+type Signer struct {
+	network, address, token string
+	timeout                 time.Duration
+	addr                    common.Address
+
+	mu   sync.Mutex
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+var _ app.Signer = (*Signer)(nil)
+
+// Dial connects to the signer server at the given address, e.g.,
+// Dial("unix", "/run/credpay/signer.sock", token), authenticates with the
+// given token and queries the address of the signing key.
+func Dial(network, address, token string) (*Signer, error) {
+	s := &Signer{
+		network: network,
+		address: address,
+		token:   token,
+		timeout: DefaultTimeout,
+	}
+	resp, err := s.call(&request{Op: opAddress})
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("querying address: %w", err)
+	}
+	s.addr = resp.Address
+	return s, nil
+}
+
+// Address returns the address of the remote signing key.
+func (s *Signer) Address() common.Address {
+	return s.addr
+}
+
+// SignCredential requests a signature on the given credential message from
+// the server and checks that it was made by the remote signing key.
+func (s *Signer) SignCredential(m *app.CredentialMessage) ([data.SigLen]byte, error) {
+	h, err := m.Hash()
+	if err != nil {
+		return [data.SigLen]byte{}, fmt.Errorf("computing credential hash: %w", err)
+	}
+	return s.sign(&request{Op: opSignCredential, Credential: newCredentialMessage(m)}, h)
+}
+
+// SignOffer requests a signature on the given offer from the server and
+// checks that it was made by the remote signing key.
+func (s *Signer) SignOffer(o *data.Offer) ([data.SigLen]byte, error) {
+	h, err := o.Hash()
+	if err != nil {
+		return [data.SigLen]byte{}, fmt.Errorf("hashing offer: %w", err)
+	}
+	return s.sign(&request{Op: opSignOffer, Offer: o}, h)
+}
+
+// sign sends the signing request and checks that the returned signature is
+// on the expected hash.
+func (s *Signer) sign(req *request, h [data.HashLen]byte) ([data.SigLen]byte, error) {
+	var sig [data.SigLen]byte
+	resp, err := s.call(req)
+	if err != nil {
+		return sig, err
+	} else if len(resp.Signature) != data.SigLen {
+		return sig, fmt.Errorf("invalid signature length: %d", len(resp.Signature))
+	}
+	copy(sig[:], resp.Signature)
+	if err := app.VerifySig(sig, h, s.addr); err != nil {
+		return sig, fmt.Errorf("verifying remote signature: %w", err)
+	}
+	return sig, nil
+}
+
+// Close closes the connection to the server.
+func (s *Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *Signer) call(req *request) (*response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := s.roundTrip(req)
+	if err != nil {
+		// The stream may be out of sync, e.g., after a timeout.
+		s.conn.Close()
+		s.conn = nil
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// connect dials the server and authenticates.
+func (s *Signer) connect() error {
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return fmt.Errorf("dialing signer: %w", err)
+	}
+	s.conn = conn
+	s.enc = json.NewEncoder(conn)
+	s.dec = json.NewDecoder(bufio.NewReader(conn))
+
+	resp, err := s.roundTrip(&request{Op: opAuth, Token: s.token})
+	if err == nil && resp.Error != "" {
+		err = errors.New(resp.Error)
+	}
+	if err != nil {
+		conn.Close()
+		s.conn = nil
+		return fmt.Errorf("authenticating: %w", err)
+	}
+	return nil
+}
+
+func (s *Signer) roundTrip(req *request) (*response, error) {
+	if err := s.conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		return nil, fmt.Errorf("setting deadline: %w", err)
+	}
+	if err := s.enc.Encode(req); err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	var resp response
+	if err := s.dec.Decode(&resp); err != nil {
+		return nil, fmt.Errorf("receiving response: %w", err)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,78 @@
+// Package remotesigner provides a Signer that forwards signing requests over
+// a socket to a separate signer process, so that the issuer keys do not have
+// to live in the channel daemon.
+//
+// Requests and responses are JSON objects, one per line. The first request on
+// a connection must authenticate with a token that is shared between the
+// server and its signers. The server closes connections that fail to
+// authenticate.
+//
+// Signing requests contain the fields of a credential or an offer and the
+// server computes the signed hash itself. It does not sign raw hashes, so
+// that a compromised daemon cannot obtain signatures on arbitrary messages.
+package remotesigner
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"perun.network/go-perun/channel"
+)
+
+const (
+	opAuth           = "auth"
+	opAddress        = "address"
+	opSignCredential = "signCredential"
+	opSignOffer      = "signOffer"
+)
+
+type request struct {
+	Op         string             `json:"op"`
+	Token      string             `json:"token,omitempty"`
+	Credential *credentialMessage `json:"credential,omitempty"`
+	Offer      *data.Offer        `json:"offer,omitempty"`
+}
+
+// credentialMessage is the JSON encoding of an app.CredentialMessage.
+type credentialMessage struct {
+	DocHash   common.Hash    `json:"docHash"`
+	ChannelID common.Hash    `json:"channelId"`
+	Holder    common.Address `json:"holder"`
+	Contract  common.Address `json:"contract"`
+	Issuer    common.Address `json:"issuer"`
+	Scheme    app.SigScheme  `json:"scheme"`
+	ChainID   *hexutil.Big   `json:"chainId,omitempty"`
+}
+
+func newCredentialMessage(m *app.CredentialMessage) *credentialMessage {
+	return &credentialMessage{
+		DocHash:   m.DocHash,
+		ChannelID: common.Hash(m.ChannelID),
+		Holder:    m.Holder,
+		Contract:  m.Contract,
+		Issuer:    m.Issuer,
+		Scheme:    m.Scheme,
+		ChainID:   (*hexutil.Big)(m.ChainID),
+	}
+}
+
+func (m *credentialMessage) message() *app.CredentialMessage {
+	return &app.CredentialMessage{
+		DocHash: m.DocHash,
+		Issuer:  m.Issuer,
+		Scheme:  m.Scheme,
+		ChainID: m.ChainID.ToInt(),
+		Binding: app.Binding{
+			ChannelID: channel.ID(m.ChannelID),
+			Holder:    m.Holder,
+			Contract:  m.Contract,
+		},
+	}
+}
+
+type response struct {
+	Address   common.Address `json:"address,omitempty"`
+	Signature hexutil.Bytes  `json:"signature,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
@@ -0,0 +1,150 @@
+package remotesigner_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+	"github.com/perun-network/perun-credential-payment/pkg/remotesigner"
+	"github.com/stretchr/testify/require"
+	ethwallet "perun.network/go-perun/backend/ethereum/wallet"
+	"perun.network/go-perun/backend/ethereum/wallet/simple"
+	"perun.network/go-perun/channel"
+)
+
+const token = "secret"
+
+// listener records the accepted connections so that tests can break them.
+type listener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (l *listener) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		c.Close()
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	require := require.New(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	acc, err := simple.NewWallet(key).Unlock(ethwallet.AsWalletAddr(addr))
+	require.NoError(err)
+
+	socket := filepath.Join(t.TempDir(), "signer.sock")
+	ul, err := net.Listen("unix", socket)
+	require.NoError(err)
+	l := &listener{Listener: ul}
+	srv, err := remotesigner.NewServer(app.NewAccountSigner(acc.(*simple.Account)), token)
+	require.NoError(err)
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+
+	_, err = remotesigner.Dial("unix", socket, "wrong")
+	require.Error(err)
+
+	signer, err := remotesigner.Dial("unix", socket, token)
+	require.NoError(err)
+	defer signer.Close()
+	require.Equal(addr, signer.Address())
+
+	m := &app.CredentialMessage{
+		DocHash: app.ComputeDocumentHash([]byte("document")),
+		Issuer:  addr,
+		Scheme:  app.SchemeEIP712,
+		ChainID: big.NewInt(1337),
+		Binding: app.Binding{ChannelID: channel.ID{1}, Holder: common.Address{2}, Contract: common.Address{3}},
+	}
+	h, err := m.Hash()
+	require.NoError(err)
+	sig, err := signer.SignCredential(m)
+	require.NoError(err)
+	require.NoError(app.VerifySig(sig, h, addr))
+
+	// The server only signs credentials of its issuer.
+	foreign := *m
+	foreign.Issuer = common.Address{4}
+	_, err = signer.SignCredential(&foreign)
+	require.Error(err)
+
+	offer := &data.Offer{ID: 1, Issuers: []common.Address{addr}, ChainID: big.NewInt(1337)}
+	oh, err := offer.Hash()
+	require.NoError(err)
+	sig, err = signer.SignOffer(offer)
+	require.NoError(err)
+	require.NoError(app.VerifySig(sig, oh, addr))
+
+	// After a broken connection, the signer reconnects.
+	l.closeConns()
+	_, err = signer.SignCredential(m)
+	require.Error(err)
+	sig, err = signer.SignCredential(m)
+	require.NoError(err)
+	require.NoError(app.VerifySig(sig, h, addr))
+
+	require.NoError(srv.Close())
+	require.NoError(<-done)
+}
+
+func TestServer_RawHash(t *testing.T) {
+	require := require.New(t)
+	key, err := crypto.GenerateKey()
+	require.NoError(err)
+	acc, err := simple.NewWallet(key).Unlock(ethwallet.AsWalletAddr(crypto.PubkeyToAddress(key.PublicKey)))
+	require.NoError(err)
+	srv, err := remotesigner.NewServer(app.NewAccountSigner(acc.(*simple.Account)), token)
+	require.NoError(err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	go srv.Serve(l)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(err)
+	defer conn.Close()
+	enc, dec := json.NewEncoder(conn), json.NewDecoder(conn)
+
+	var resp struct {
+		Signature string `json:"signature"`
+		Error     string `json:"error"`
+	}
+	require.NoError(enc.Encode(map[string]string{"op": "auth", "token": token}))
+	require.NoError(dec.Decode(&resp))
+	require.Empty(resp.Error)
+
+	h := crypto.Keccak256Hash([]byte("transaction"))
+	require.NoError(enc.Encode(map[string]string{"op": "sign", "hash": h.Hex()}))
+	require.NoError(dec.Decode(&resp))
+	require.Empty(resp.Signature)
+	require.Contains(resp.Error, "unknown operation")
+}
+
+func TestNewServer_EmptyToken(t *testing.T) {
+	_, err := remotesigner.NewServer(nil, "")
+	require.Error(t, err)
+}
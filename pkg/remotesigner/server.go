@@ -0,0 +1,139 @@
+package remotesigner
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/app/data"
+)
+
+// Server answers the signing requests of remote signers with the given
+// signer.
+type Server struct {
+	signer app.Signer
+	token  string
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	closed    bool
+}
+
+// NewServer returns a server that signs with the given signer for remote
+// signers that authenticate with the given token. The token must not be
+// empty.
+func NewServer(signer app.Signer, token string) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("empty token")
+	}
+	return &Server{signer: signer, token: token}, nil
+}
+
+// Serve accepts connections on the given listener and answers their requests
+// until the listener fails or the server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return net.ErrClosed
+	}
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() && errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("accepting connection: %w", err)
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close closes all listeners of the server.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var err error
+	for _, l := range s.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	dec := json.NewDecoder(bufio.NewReader(conn))
+	enc := json.NewEncoder(conn)
+
+	var auth request
+	if err := dec.Decode(&auth); err != nil {
+		return
+	} else if auth.Op != opAuth || subtle.ConstantTimeCompare([]byte(auth.Token), []byte(s.token)) != 1 {
+		_ = enc.Encode(&response{Error: "unauthorized"})
+		return
+	} else if err := enc.Encode(&response{}); err != nil {
+		return
+	}
+
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		if err := enc.Encode(s.respond(&req)); err != nil {
+			log.Printf("remote signer: writing response: %v", err)
+			return
+		}
+	}
+}
+
+func (s *Server) respond(req *request) *response {
+	switch req.Op {
+	case opAddress:
+		return &response{Address: s.signer.Address()}
+	case opSignCredential:
+		if req.Credential == nil {
+			return &response{Error: "missing credential"}
+		}
+		m := req.Credential.message()
+		if issuer, _ := app.IssuerOf(s.signer); m.Issuer != issuer {
+			return &response{Error: fmt.Sprintf("foreign issuer: %v", m.Issuer)}
+		}
+		return signResponse(s.signer.SignCredential(m))
+	case opSignOffer:
+		if req.Offer == nil {
+			return &response{Error: "missing offer"}
+		} else if len(req.Offer.Issuers) == 0 || req.Offer.Issuers[0] != s.signer.Address() {
+			// Quotes are verified against the first issuer.
+			return &response{Error: "not the first issuer of the offer"}
+		}
+		return signResponse(s.signer.SignOffer(req.Offer))
+	default:
+		return &response{Error: fmt.Sprintf("unknown operation: %s", req.Op)}
+	}
+}
+
+func signResponse(sig [data.SigLen]byte, err error) *response {
+	if err != nil {
+		return &response{Error: err.Error()}
+	}
+	return &response{Signature: sig[:]}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/perun-network/perun-credential-payment/app"
+	"github.com/perun-network/perun-credential-payment/pkg/deploy"
 	"github.com/pkg/errors"
 	"perun.network/go-perun/backend/ethereum/wallet"
 	"perun.network/go-perun/channel"
@@ -14,6 +15,7 @@ import (
 
 type ContractAddresses struct {
 	Adjudicator, AssetHolder, App common.Address
+	Token, AssetHolderERC20       common.Address
 }
 
 func deployContracts(
@@ -21,42 +23,30 @@ func deployContracts(
 	nodeURL string,
 	chainID *big.Int,
 	deploymentKey *ecdsa.PrivateKey,
+	tokenHolders []common.Address,
+	tokenBalance *big.Int,
 ) (ContractAddresses, error) {
-	c, err := NewEthClient(ctx, nodeURL, deploymentKey, chainID)
+	d, err := deploy.NewDeployer(ctx, nodeURL, deploymentKey, chainID)
 	if err != nil {
-		return ContractAddresses{}, errors.WithMessage(err, "creating ethereum client")
+		return ContractAddresses{}, errors.WithMessage(err, "creating deployer")
 	}
 
-	// Deploy adjudicator.
-	adj, txAdj, err := c.DeployAdjudicator(ctx)
+	m, err := deploy.Deploy(ctx, d, nil, deploy.Options{
+		TestToken: &deploy.TestToken{Holders: tokenHolders, Balance: tokenBalance},
+	})
 	if err != nil {
-		return ContractAddresses{}, errors.WithMessage(err, "deploying adjudicator")
-	}
-
-	// Deploy app.
-	appAddr, txApp, err := c.DeployApp(ctx, adj)
-	if err != nil {
-		return ContractAddresses{}, errors.WithMessage(err, "deploying CollateralApp")
+		return ContractAddresses{}, errors.WithMessage(err, "deploying contracts")
 	}
 
 	// Register app.
-	swapApp := app.NewCredentialSwapApp(wallet.AsWalletAddr(appAddr))
+	swapApp := app.NewCredentialSwapApp(wallet.AsWalletAddr(m.App))
 	channel.RegisterApp(swapApp)
 
-	// Deploy asset holder.
-	assetHolderAddr, txAss, err := c.DeployAssetHolderETH(ctx, adj, appAddr)
-	if err != nil {
-		return ContractAddresses{}, errors.WithMessage(err, "deploying CollateralAssetHolderETH")
-	}
-
-	err = c.WaitDeployment(ctx, txAdj, txApp, txAss)
-	if err != nil {
-		return ContractAddresses{}, errors.WithMessage(err, "waiting for contract deployment")
-	}
-
 	return ContractAddresses{
-		Adjudicator: adj,
-		AssetHolder: assetHolderAddr,
-		App:         appAddr,
+		Adjudicator:      m.Adjudicator,
+		AssetHolder:      m.AssetHolder,
+		App:              m.App,
+		Token:            m.ERC20[0].Token,
+		AssetHolderERC20: m.ERC20[0].AssetHolder,
 	}, nil
 }
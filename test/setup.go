@@ -28,6 +28,8 @@ const (
 
 	disputeDuration = 3 * time.Second
 
+	tokenBalanceEth = 10
+
 	// Client hosts.
 	holderHost = "127.0.0.1:8546"
 	issuerHost = "127.0.0.1:8547"
@@ -43,6 +45,9 @@ var accountFunding = []ganache.KeyWithBalance{
 type Environment struct {
 	Holder, Issuer *client.Client
 	Ganache        *ganache.Ganache
+	Contracts      ContractAddresses
+
+	holderConfig client.ClientConfig
 }
 
 func (e *Environment) LogAccountBalances() {
@@ -73,7 +78,9 @@ func Setup(t *testing.T) *Environment {
 	log.Print("Deploying contracts...")
 	nodeURL := ganacheCfg.NodeURL()
 	deploymentKey := ganache.Accounts[0].PrivateKey
-	contracts, err := deployContracts(ctx, nodeURL, ganacheCfg.ChainID, deploymentKey)
+	tokenHolders := []common.Address{ganache.Accounts[1].Address(), ganache.Accounts[2].Address()}
+	tokenBalance := EthToWei(big.NewFloat(tokenBalanceEth))
+	contracts, err := deployContracts(ctx, nodeURL, ganacheCfg.ChainID, deploymentKey, tokenHolders, tokenBalance)
 	require.NoError(err, "deploying contracts")
 
 	log.Print("Setting up clients...")
@@ -83,9 +90,12 @@ func Setup(t *testing.T) *Environment {
 		ganache.Accounts[1].PrivateKey, holderHost,
 		ganache.Accounts[2].Address(), issuerHost,
 	)
+	holderConfig.DatabasePath = t.TempDir()
 	holder, err := client.StartClient(ctx, holderConfig)
 	require.NoError(err, "Holder setup")
-	t.Cleanup(holder.Shutdown)
+	env := &Environment{Holder: holder, Ganache: ganache, Contracts: contracts, holderConfig: holderConfig}
+	// The holder may be restarted.
+	t.Cleanup(func() { env.Holder.Shutdown() })
 
 	// Setup issuer.
 	issuerConfig := newClientConfig(
@@ -96,9 +106,21 @@ func Setup(t *testing.T) *Environment {
 	issuer, err := client.StartClient(ctx, issuerConfig)
 	require.NoError(err, "Issuer setup")
 	t.Cleanup(issuer.Shutdown)
+	env.Issuer = issuer
 
 	log.Print("Setup done.")
-	return &Environment{Holder: holder, Issuer: issuer, Ganache: ganache}
+	return env
+}
+
+// RestartHolder shuts down the holder and starts it again on the same
+// database, which restores its channels.
+func (e *Environment) RestartHolder(ctx context.Context, t *testing.T) {
+	t.Helper()
+	log.Print("Restarting holder...")
+	e.Holder.Shutdown()
+	holder, err := client.StartClient(ctx, e.holderConfig)
+	require.NoError(t, err, "Holder restart")
+	e.Holder = holder
 }
 
 func makeGanacheConfig(funding []ganache.KeyWithBalance) ganache.GanacheConfig {
@@ -128,11 +150,14 @@ func newClientConfig(
 ) client.ClientConfig {
 	return client.ClientConfig{
 		ClientConfig: perun.ClientConfig{
-			PrivateKey:    privateKey,
-			Host:          host,
-			ETHNodeURL:    nodeURL,
-			Adjudicator:   contracts.Adjudicator,
-			AssetHolder:   contracts.AssetHolder,
+			PrivateKey:  privateKey,
+			Host:        host,
+			ETHNodeURL:  nodeURL,
+			Adjudicator: contracts.Adjudicator,
+			AssetHolder: contracts.AssetHolder,
+			AssetHoldersERC20: []perun.AssetHolderERC20{
+				{AssetHolder: contracts.AssetHolderERC20, Token: contracts.Token},
+			},
 			DialerTimeout: 1 * time.Second,
 			Peers: []perun.Peer{
 				{